@@ -18,6 +18,7 @@ package ceph
 
 import (
 	"fmt"
+	"net/http"
 
 	"github.com/rook/rook/cmd/rook/rook"
 	"github.com/rook/rook/pkg/clusterd"
@@ -31,6 +32,9 @@ import (
 
 const containerName = "rook-ceph-operator"
 
+// the address the health of the mons of the clusters is served on, disabled when empty
+var healthzAddr string
+
 var operatorCmd = &cobra.Command{
 	Use:   "operator",
 	Short: "Runs the Ceph operator for orchestrating and managing Ceph storage in a Kubernetes cluster",
@@ -41,7 +45,18 @@ https://github.com/rook/rook`,
 
 func init() {
 	operatorCmd.Flags().DurationVar(&mon.HealthCheckInterval, "mon-healthcheck-interval", mon.HealthCheckInterval, "mon health check interval (duration)")
+	operatorCmd.Flags().Float64Var(&mon.HealthCheckJitter, "mon-healthcheck-jitter", mon.HealthCheckJitter, "fraction of the mon health check interval by which each check is randomly moved, up to 0.5")
 	operatorCmd.Flags().DurationVar(&mon.MonOutTimeout, "mon-out-timeout", mon.MonOutTimeout, "mon out timeout (duration)")
+	operatorCmd.Flags().IntVar(&mon.RemoveMonRetries, "mon-remove-retries", mon.RemoveMonRetries, "number of attempts to remove a mon from quorum")
+	operatorCmd.Flags().DurationVar(&mon.RemoveMonBackoff, "mon-remove-backoff", mon.RemoveMonBackoff, "initial wait before retrying the removal of a mon, doubled on each retry (duration)")
+	operatorCmd.Flags().IntVar(&mon.MinMonsInQuorum, "mon-min-in-quorum", mon.MinMonsInQuorum, "least number of mons left in quorum after any mon is removed, 0 to disable")
+	operatorCmd.Flags().DurationVar(&mon.HealthyMonGracePeriod, "mon-healthy-grace-period", mon.HealthyMonGracePeriod, "grace period to delete a healthy mon, such as an extra mon (duration)")
+	operatorCmd.Flags().DurationVar(&mon.DeadMonGracePeriod, "mon-dead-grace-period", mon.DeadMonGracePeriod, "grace period to delete a mon that is out of quorum (duration)")
+	operatorCmd.Flags().DurationVar(&mon.FailoverBackoff, "mon-failover-backoff", mon.FailoverBackoff, "time the replacement of a failed mon is not failed over, doubled with each failover of the same mon, 0 to disable (duration)")
+	operatorCmd.Flags().DurationVar(&mon.MaxFailoverBackoff, "mon-max-failover-backoff", mon.MaxFailoverBackoff, "max time between the failovers of the same mon (duration)")
+	operatorCmd.Flags().DurationVar(&mon.FailedNodeCooldown, "mon-failed-node-cooldown", mon.FailedNodeCooldown, "time no new mon is placed on the node of a failed over mon, 0 to disable (duration)")
+	operatorCmd.Flags().StringVar(&healthzAddr, "healthz-addr", "", "address to serve the health and the metrics of the mons of the clusters on, such as :8080. disabled when empty")
+	operatorCmd.Flags().IntVar(&mon.MaxMonCount, "mon-max-count", mon.MaxMonCount, "highest mon count allowed for a cluster, a higher count is lowered to it")
 	flags.SetFlagsFromEnv(operatorCmd.Flags(), rook.RookEnvVarPrefix)
 	flags.SetLoggingFlags(operatorCmd.Flags())
 	operatorCmd.RunE = startOperator
@@ -81,6 +96,10 @@ func startOperator(cmd *cobra.Command, args []string) error {
 		rook.TerminateFatal(fmt.Errorf("failed to get container image. %+v\n", err))
 	}
 
+	if healthzAddr != "" {
+		serveHealthz(healthzAddr)
+	}
+
 	op := operator.New(context, volumeAttachment, rookImage, pod.Spec.ServiceAccountName)
 	err = op.Run()
 	if err != nil {
@@ -89,3 +108,13 @@ func startOperator(cmd *cobra.Command, args []string) error {
 
 	return nil
 }
+
+// serveHealthz serves the health of the mons of the clusters for the liveness and readiness probes, and
+// the metrics of the mons for prometheus
+func serveHealthz(addr string) {
+	logger.Infof("serving the health of the mons on %s%s and their metrics on %s%s", addr, mon.HealthzPath, addr, mon.MetricsPath)
+	go func() {
+		// the operator keeps running without the endpoint
+		logger.Errorf("stopped serving the health of the mons. %+v", http.ListenAndServe(addr, mon.NewHealthzMux()))
+	}()
+}
@@ -99,6 +99,28 @@ type DashboardSpec struct {
 type ClusterStatus struct {
 	State   ClusterState `json:"state,omitempty"`
 	Message string       `json:"message,omitempty"`
+	// Conditions are the latest observations of the state of the cluster
+	Conditions []ClusterCondition `json:"conditions,omitempty"`
+}
+
+// ClusterConditionType is the type of an observation of the state of the cluster
+type ClusterConditionType string
+
+const (
+	// ClusterConditionMonsHealthy is true when the desired count of mons is running and all the mons are in quorum
+	ClusterConditionMonsHealthy ClusterConditionType = "MonsHealthy"
+	// ClusterConditionDowngradeBlocked is true when the mons are not started because the ceph version is lower
+	// than the version the mons already ran
+	ClusterConditionDowngradeBlocked ClusterConditionType = "DowngradeBlocked"
+)
+
+// ClusterCondition is an observation of the state of the cluster
+type ClusterCondition struct {
+	Type               ClusterConditionType `json:"type"`
+	Status             v1.ConditionStatus   `json:"status"`
+	LastTransitionTime metav1.Time          `json:"lastTransitionTime,omitempty"`
+	Reason             string               `json:"reason,omitempty"`
+	Message            string               `json:"message,omitempty"`
 }
 
 type ClusterState string
@@ -108,11 +130,58 @@ const (
 	ClusterStateCreated  ClusterState = "Created"
 	ClusterStateUpdating ClusterState = "Updating"
 	ClusterStateError    ClusterState = "Error"
+	ClusterStateDegraded ClusterState = "Degraded"
+	// ClusterStateQuorumUnreachable is the state of a cluster whose mons cannot be reached at all
+	ClusterStateQuorumUnreachable ClusterState = "QuorumUnreachable"
 )
 
 type MonSpec struct {
-	Count                int  `json:"count"`
-	AllowMultiplePerNode bool `json:"allowMultiplePerNode"`
+	Count                int                `json:"count"`
+	AllowMultiplePerNode bool               `json:"allowMultiplePerNode"`
+	HealthCheck          MonHealthCheckSpec `json:"healthCheck,omitempty"`
+	// TopologyKey is the node label of the failure domains the mons are spread across when they are
+	// placed, such as "failure-domain.beta.kubernetes.io/zone"
+	TopologyKey string `json:"topologyKey,omitempty"`
+	// AllowEvenMonCount allows the mons to be reduced to an even desired count, which is less tolerant
+	// to the failure of a mon than an odd count
+	AllowEvenMonCount bool `json:"allowEvenMonCount,omitempty"`
+}
+
+// MonHealthCheckSpec represents the settings for checking the health of the mons
+type MonHealthCheckSpec struct {
+	// Interval is how often the mons are checked for quorum, such as "45s"
+	Interval string `json:"interval,omitempty"`
+	// Timeout is how long a mon can be out of quorum before it is failed over, such as "600s"
+	Timeout string `json:"timeout,omitempty"`
+	// MaxConcurrentFailover is the max number of mons failed over in a single health check
+	MaxConcurrentFailover int `json:"maxConcurrentFailover,omitempty"`
+	// MaxConcurrentRemoval is the max number of extra mons removed in a single health check when the
+	// mon count is reduced
+	MaxConcurrentRemoval int `json:"maxConcurrentRemoval,omitempty"`
+	// SuspendFailoverUntil suspends the failover, removal and moves of mons until the given RFC3339 time,
+	// such as during node maintenance. The suspension ends on its own after at most 24 hours.
+	SuspendFailoverUntil string `json:"suspendFailoverUntil,omitempty"`
+	// DryRun logs the mon failovers, removals and additions the health check would make without making them
+	DryRun bool `json:"dryRun,omitempty"`
+	// MaxFailoverAttempts is the number of times a mon and its replacements are failed over without joining
+	// quorum before the failover stops and the cluster is marked as degraded
+	MaxFailoverAttempts int `json:"maxFailoverAttempts,omitempty"`
+	// PauseFailoverOnMixedVersions pauses the failover of mons while the mons run mixed ceph versions,
+	// such as in the middle of an upgrade
+	PauseFailoverOnMixedVersions bool `json:"pauseFailoverOnMixedVersions,omitempty"`
+	// DeferFailoverOnHealthErr defers the failover of mons while the cluster is in HEALTH_ERR for reasons
+	// other than the mons, such as full OSDs, unless losing another mon would break quorum
+	DeferFailoverOnHealthErr bool `json:"deferFailoverOnHealthErr,omitempty"`
+	// RebalanceToPreferredNodes moves the mons one at a time back to the nodes preferred by the node
+	// affinity of the mon placement while all the mons are healthy
+	RebalanceToPreferredNodes bool `json:"rebalanceToPreferredNodes,omitempty"`
+	// AllowMonRemoval allows the health check to remove the extra mons when there are more mons than the
+	// desired count. When false the extra mons are left running to be removed manually. Dead mons are still
+	// failed over. Defaults to true.
+	AllowMonRemoval *bool `json:"allowMonRemoval,omitempty"`
+	// FailoverOrder is the order in which a failed mon is replaced, "add-then-remove" (the default) or
+	// "remove-dead-first" to remove a mon before its replacement is started when the mon is confirmed dead
+	FailoverOrder string `json:"failoverOrder,omitempty"`
 }
 
 type RBDMirroringSpec struct {
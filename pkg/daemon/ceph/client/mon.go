@@ -16,12 +16,17 @@ limitations under the License.
 package client
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"time"
 
 	"github.com/rook/rook/pkg/clusterd"
 )
 
+// how often the mon status is checked while waiting for quorum
+var quorumPollInterval = 5 * time.Second
+
 // represents the response from a mon_status mon_command (subset of all available fields, only
 // marshal ones we care about)
 type MonStatusResponse struct {
@@ -67,6 +72,89 @@ func GetMonStatus(context *clusterd.Context, clusterName string, debug bool) (Mo
 	return resp, nil
 }
 
+// WaitForQuorum waits until the expected number of mons are in quorum, the timeout expires or ctx is cancelled
+func WaitForQuorum(ctx context.Context, context *clusterd.Context, clusterName string, expected int, timeout time.Duration) error {
+	return waitForMonStatus(ctx, context, clusterName, fmt.Sprintf("%d mons in quorum", expected), timeout, func(status MonStatusResponse) bool {
+		return len(status.Quorum) >= expected
+	})
+}
+
+// WaitForMonInQuorum waits until the mon is in quorum, the timeout expires or ctx is cancelled. Other mons
+// may still be out of quorum.
+func WaitForMonInQuorum(ctx context.Context, context *clusterd.Context, clusterName, name string, timeout time.Duration) error {
+	return waitForMonStatus(ctx, context, clusterName, fmt.Sprintf("mon %s in quorum", name), timeout, func(status MonStatusResponse) bool {
+		return MonInQuorum(status, name)
+	})
+}
+
+// waitForMonStatus polls the mon status until the condition is met, the timeout expires or ctx is cancelled.
+// The condition is described by what in the logs and errors.
+func waitForMonStatus(ctx context.Context, context *clusterd.Context, clusterName, what string, timeout time.Duration, done func(MonStatusResponse) bool) error {
+	logger.Infof("waiting for %s", what)
+	deadline := time.Now().Add(timeout)
+	for {
+		status, err := GetMonStatus(context, clusterName, false)
+		if err != nil {
+			logger.Warningf("failed to get mon status while waiting for %s. %+v", what, err)
+		} else if done(status) {
+			logger.Infof("found %s, %d mons in quorum", what, len(status.Quorum))
+			return nil
+		} else {
+			logger.Infof("waiting for %s, %d mons in quorum", what, len(status.Quorum))
+		}
+
+		if time.Now().Add(quorumPollInterval).After(deadline) {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("stopped waiting for %s. %+v", what, ctx.Err())
+		case <-time.After(quorumPollInterval):
+		}
+	}
+
+	return fmt.Errorf("gave up waiting for %s after %s", what, timeout)
+}
+
+// MonInQuorum returns whether the mon is in the mon map and its rank is in quorum
+func MonInQuorum(status MonStatusResponse, name string) bool {
+	for _, mon := range status.MonMap.Mons {
+		if mon.Name != name {
+			continue
+		}
+		for _, rank := range status.Quorum {
+			if rank == mon.Rank {
+				return true
+			}
+		}
+		return false
+	}
+	return false
+}
+
+// CephDaemonsVersions is a subset of the response from the "versions" command, which counts the daemons
+// running each version
+type CephDaemonsVersions struct {
+	Mon map[string]int `json:"mon"`
+}
+
+// GetMonVersions returns the number of mons running each version. The versions are in the form printed
+// by `ceph --version`.
+func GetMonVersions(context *clusterd.Context, clusterName string) (map[string]int, error) {
+	args := []string{"versions"}
+	buf, err := ExecuteCephCommand(context, clusterName, args)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get versions: %+v", err)
+	}
+
+	var versions CephDaemonsVersions
+	if err := json.Unmarshal(buf, &versions); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal versions response: %+v", err)
+	}
+
+	return versions.Mon, nil
+}
+
 // MonStats is a subset of fields on the response from the mon command "status".  These fields
 // are focused on monitor stats.
 type MonStats struct {
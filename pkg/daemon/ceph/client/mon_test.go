@@ -16,9 +16,14 @@ limitations under the License.
 package client
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"testing"
+	"time"
 
+	"github.com/rook/rook/pkg/clusterd"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -63,3 +68,78 @@ func TestCephArgs(t *testing.T) {
 	assert.Equal(t, 1, len(args))
 	assert.Equal(t, "myarg", args[0])
 }
+
+func TestWaitForQuorum(t *testing.T) {
+	quorumPollInterval = time.Millisecond
+	// a mon joins the quorum each time the status is checked
+	statusCalls := 0
+	executor := &exectest.MockExecutor{}
+	executor.MockExecuteCommandWithOutputFile = func(debug bool, actionName, command, outputFile string, args ...string) (string, error) {
+		if args[0] != "mon_status" {
+			return "", fmt.Errorf("unexpected ceph command '%v'", args)
+		}
+		statusCalls++
+		status := MonStatusResponse{}
+		for i := 0; i < statusCalls && i < 3; i++ {
+			status.Quorum = append(status.Quorum, i)
+		}
+		output, _ := json.Marshal(status)
+		return string(output), nil
+	}
+	clusterdContext := &clusterd.Context{Executor: executor}
+
+	// returns once the quorum reaches the expected size
+	err := WaitForQuorum(context.Background(), clusterdContext, "rook", 3, 5*time.Second)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, statusCalls)
+
+	// times out when the quorum never reaches the expected size
+	err = WaitForQuorum(context.Background(), clusterdContext, "rook", 4, 10*time.Millisecond)
+	assert.NotNil(t, err)
+
+	// stops when the context is cancelled
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = WaitForQuorum(ctx, clusterdContext, "rook", 4, time.Hour)
+	assert.NotNil(t, err)
+}
+
+func TestWaitForMonInQuorum(t *testing.T) {
+	quorumPollInterval = time.Millisecond
+	// mon d joins the quorum the third time the status is checked, mon c stays out of quorum
+	statusCalls := 0
+	executor := &exectest.MockExecutor{}
+	executor.MockExecuteCommandWithOutputFile = func(debug bool, actionName, command, outputFile string, args ...string) (string, error) {
+		if args[0] != "mon_status" {
+			return "", fmt.Errorf("unexpected ceph command '%v'", args)
+		}
+		statusCalls++
+		status := MonStatusResponse{Quorum: []int{0, 1}}
+		status.MonMap.Mons = []MonMapEntry{{Name: "a", Rank: 0}, {Name: "b", Rank: 1}, {Name: "c", Rank: 2}, {Name: "d", Rank: 3}}
+		if statusCalls >= 3 {
+			status.Quorum = append(status.Quorum, 3)
+		}
+		output, _ := json.Marshal(status)
+		return string(output), nil
+	}
+	clusterdContext := &clusterd.Context{Executor: executor}
+
+	// returns once the mon is in quorum even though another mon is not
+	err := WaitForMonInQuorum(context.Background(), clusterdContext, "rook", "d", 5*time.Second)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, statusCalls)
+
+	// times out when the mon never joins quorum
+	err = WaitForMonInQuorum(context.Background(), clusterdContext, "rook", "c", 10*time.Millisecond)
+	assert.NotNil(t, err)
+
+	// times out when the mon is not in the mon map
+	err = WaitForMonInQuorum(context.Background(), clusterdContext, "rook", "e", 10*time.Millisecond)
+	assert.NotNil(t, err)
+
+	// stops when the context is cancelled
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = WaitForMonInQuorum(ctx, clusterdContext, "rook", "c", time.Hour)
+	assert.NotNil(t, err)
+}
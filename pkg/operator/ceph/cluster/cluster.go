@@ -32,6 +32,7 @@ import (
 	"github.com/rook/rook/pkg/operator/ceph/cluster/mon"
 	"github.com/rook/rook/pkg/operator/ceph/cluster/osd"
 	"github.com/rook/rook/pkg/operator/ceph/cluster/rbd"
+	cephver "github.com/rook/rook/pkg/operator/ceph/version"
 	"github.com/rook/rook/pkg/operator/k8sutil"
 	batch "k8s.io/api/batch/v1"
 	"k8s.io/api/core/v1"
@@ -57,6 +58,8 @@ type cluster struct {
 	mons      *mon.Cluster
 	stopCh    chan struct{}
 	ownerRef  metav1.OwnerReference
+	// the full version detected on the ceph image
+	cephVersion cephver.CephVersion
 }
 
 func newCluster(c *cephv1.CephCluster, context *clusterd.Context) *cluster {
@@ -113,6 +116,12 @@ func (c *cluster) detectCephMajorVersion(image string, timeout time.Duration) (s
 	if err != nil {
 		return "", fmt.Errorf("failed to extract ceph version. %+v", err)
 	}
+	fullVersion, err := cephver.ExtractCephVersion(log)
+	if err != nil {
+		logger.Warningf("failed to extract the full ceph version. %+v", err)
+	} else {
+		c.cephVersion = *fullVersion
+	}
 
 	// delete the job since we're done with it
 	k8sutil.DeleteBatchJob(c.context.Clientset, c.Namespace, job.Name, false)
@@ -142,8 +151,7 @@ func (c *cluster) createInstance(rookImage string) error {
 	}
 
 	// Start the mon pods
-	c.mons = mon.New(c.context, c.Namespace, c.Spec.DataDirHostPath, rookImage, c.Spec.CephVersion, c.Spec.Mon, cephv1.GetMonPlacement(c.Spec.Placement),
-		c.Spec.Network.HostNetwork, cephv1.GetMonResources(c.Spec.Resources), c.ownerRef)
+	c.initMons(rookImage)
 	err = c.mons.Start()
 	if err != nil {
 		return fmt.Errorf("failed to start the mons. %+v", err)
@@ -181,6 +189,21 @@ func (c *cluster) createInstance(rookImage string) error {
 	return nil
 }
 
+// initMons creates the mons the first time the cluster is created. The mons are updated in place for an
+// update of the cluster so the running health checker and the changes applied by clusterChanged act on the
+// same mons.
+func (c *cluster) initMons(rookImage string) {
+	if c.mons == nil {
+		c.mons = mon.New(c.context, c.Namespace, c.Spec.DataDirHostPath, rookImage, c.Spec.CephVersion, c.Spec.Mon, cephv1.GetMonPlacement(c.Spec.Placement),
+			c.Spec.Network.HostNetwork, cephv1.GetMonResources(c.Spec.Resources), c.ownerRef)
+		c.mons.SetEventRecorder(k8sutil.NewEventRecorder(c.context.Clientset, c.Namespace, "rook-ceph-operator"))
+	} else {
+		c.mons.Update(rookImage, c.Spec.CephVersion, c.Spec.Mon, cephv1.GetMonPlacement(c.Spec.Placement),
+			c.Spec.Network.HostNetwork, cephv1.GetMonResources(c.Spec.Resources))
+	}
+	c.mons.SetCephVersion(c.cephVersion)
+}
+
 func (c *cluster) createInitialCrushMap() error {
 	configMapExists := false
 	createCrushMap := false
@@ -276,6 +299,18 @@ func clusterChanged(oldCluster, newCluster cephv1.ClusterSpec, clusterRef *clust
 		clusterRef.mons.MonCountMutex.Unlock()
 	}
 
+	if oldCluster.Mon.AllowEvenMonCount != newCluster.Mon.AllowEvenMonCount {
+		logger.Infof("allow even mon count changed from %t to %t. The health check will update the mons...", oldCluster.Mon.AllowEvenMonCount, newCluster.Mon.AllowEvenMonCount)
+		clusterRef.mons.MonCountMutex.Lock()
+		clusterRef.mons.AllowEvenMonCount = newCluster.Mon.AllowEvenMonCount
+		clusterRef.mons.MonCountMutex.Unlock()
+	}
+
+	if healthCheckChanged(oldCluster.Mon.HealthCheck, newCluster.Mon.HealthCheck) {
+		logger.Infof("mon health check settings changed from %+v to %+v", oldCluster.Mon.HealthCheck, newCluster.Mon.HealthCheck)
+		clusterRef.mons.UpdateHealthCheck(newCluster.Mon.HealthCheck)
+	}
+
 	if oldCluster.RBDMirroring.Workers != newCluster.RBDMirroring.Workers {
 		logger.Infof("rbd mirrors changed from %d to %d", oldCluster.RBDMirroring.Workers, newCluster.RBDMirroring.Workers)
 		changeFound = true
@@ -294,6 +329,12 @@ func clusterChanged(oldCluster, newCluster cephv1.ClusterSpec, clusterRef *clust
 	return changeFound
 }
 
+// healthCheckChanged compares the values of the mon health check settings. Settings such as allowMonRemoval
+// are pointers that differ between the old and the new cluster even when their values are the same.
+func healthCheckChanged(old, new cephv1.MonHealthCheckSpec) bool {
+	return !reflect.DeepEqual(old, new)
+}
+
 func extractCephVersion(version string) (string, error) {
 	for _, v := range allVersions {
 		if strings.Contains(version, v) {
@@ -18,6 +18,7 @@ limitations under the License.
 package cluster
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"reflect"
@@ -178,7 +179,11 @@ func (c *ClusterController) onAdd(obj interface{}) {
 		cluster.Spec.Mon.Count = mon.MaxMonCount
 	}
 	if cluster.Spec.Mon.Count%2 == 0 {
-		logger.Warningf("mon count is even (given: %d), should be uneven, continuing", cluster.Spec.Mon.Count)
+		if cluster.Spec.Mon.AllowEvenMonCount {
+			logger.Warningf("mon count is even (given: %d) and allowed by allowEvenMonCount. an even mon count tolerates no more mon failures than %d mons, quorum is at risk", cluster.Spec.Mon.Count, cluster.Spec.Mon.Count-1)
+		} else {
+			logger.Warningf("mon count is even (given: %d), should be uneven, continuing", cluster.Spec.Mon.Count)
+		}
 	}
 
 	cluster.Spec.CephVersion.Name, err = cluster.detectCephMajorVersion(cluster.Spec.CephVersion.Image, 15*time.Minute)
@@ -242,7 +247,7 @@ func (c *ClusterController) onAdd(obj interface{}) {
 
 	// Start mon health checker
 	healthChecker := mon.NewHealthChecker(cluster.mons)
-	go healthChecker.Check(cluster.stopCh)
+	go healthChecker.Check(contextFromStopCh(cluster.stopCh))
 
 	// Start the osd health checker
 	osdChecker := osd.NewMonitor(c.context, cluster.Namespace)
@@ -553,6 +558,16 @@ func (c *ClusterController) removeFinalizer(obj interface{}) {
 	logger.Warningf("giving up from removing the %s cluster finalizer", fname)
 }
 
+// contextFromStopCh returns a context that is cancelled when the stop channel is closed
+func contextFromStopCh(stopCh chan struct{}) context.Context {
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-stopCh
+		cancel()
+	}()
+	return ctx
+}
+
 func (c *ClusterController) updateClusterStatus(namespace, name string, state cephv1.ClusterState, message string) error {
 	// get the most recent cluster CRD object
 	cluster, err := c.context.RookClientset.CephV1().CephClusters(namespace).Get(name, metav1.GetOptions{})
@@ -561,7 +576,9 @@ func (c *ClusterController) updateClusterStatus(namespace, name string, state ce
 	}
 
 	// update the status on the retrieved cluster object
-	cluster.Status = cephv1.ClusterStatus{State: state, Message: message}
+	// keep the conditions of the cluster
+	cluster.Status.State = state
+	cluster.Status.Message = message
 	if _, err := c.context.RookClientset.CephV1().CephClusters(cluster.Namespace).Update(cluster); err != nil {
 		return fmt.Errorf("failed to update cluster %s status: %+v", cluster.Namespace, err)
 	}
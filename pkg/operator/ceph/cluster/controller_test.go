@@ -29,6 +29,7 @@ import (
 	"github.com/rook/rook/pkg/clusterd"
 	"github.com/rook/rook/pkg/daemon/ceph/agent/flexvolume/attachment"
 	"github.com/rook/rook/pkg/operator/ceph/cluster/mon"
+	cephver "github.com/rook/rook/pkg/operator/ceph/version"
 	"github.com/rook/rook/pkg/operator/k8sutil"
 	testop "github.com/rook/rook/pkg/operator/test"
 	exectest "github.com/rook/rook/pkg/util/exec/test"
@@ -166,6 +167,48 @@ func TestClusterChanged(t *testing.T) {
 	assert.True(t, c.mons.AllowMultiplePerNode)
 }
 
+func TestHealthCheckChanged(t *testing.T) {
+	allowRemoval, stillAllowRemoval, denyRemoval := true, true, false
+	old := cephv1.MonHealthCheckSpec{Interval: "1m", AllowMonRemoval: &allowRemoval}
+
+	// the same values in different pointers are not a change
+	assert.False(t, healthCheckChanged(old, cephv1.MonHealthCheckSpec{Interval: "1m", AllowMonRemoval: &stillAllowRemoval}))
+	assert.False(t, healthCheckChanged(cephv1.MonHealthCheckSpec{}, cephv1.MonHealthCheckSpec{}))
+
+	// a changed value is a change, whether it is a pointer or not
+	assert.True(t, healthCheckChanged(old, cephv1.MonHealthCheckSpec{Interval: "1m", AllowMonRemoval: &denyRemoval}))
+	assert.True(t, healthCheckChanged(old, cephv1.MonHealthCheckSpec{Interval: "1m"}))
+	assert.True(t, healthCheckChanged(old, cephv1.MonHealthCheckSpec{Interval: "2m", AllowMonRemoval: &stillAllowRemoval}))
+}
+
+func TestInitMons(t *testing.T) {
+	context := &clusterd.Context{Clientset: testop.New(3)}
+	c := newCluster(&cephv1.CephCluster{ObjectMeta: metav1.ObjectMeta{Name: "rook", Namespace: "ns"}}, context)
+	c.Spec.Mon.Count = 3
+	c.cephVersion = cephver.Luminous
+	c.initMons("rook/rook:v0.9.0")
+	mons := c.mons
+	assert.NotNil(t, mons)
+	assert.Equal(t, 3, mons.Count)
+	assert.Equal(t, cephver.Luminous, mons.CephVersion())
+
+	// the mons are updated in place for an update of the cluster so the health checker sees the new settings
+	// and the version of the upgraded ceph image
+	c.Spec.Mon.AllowMultiplePerNode = true
+	c.cephVersion = cephver.Mimic
+	c.initMons("rook/rook:v0.9.1")
+	assert.True(t, mons == c.mons)
+	assert.True(t, mons.AllowMultiplePerNode)
+	assert.Equal(t, cephver.Mimic, mons.CephVersion())
+
+	// the changes of the next update also reach the mons of the health checker
+	old := *c.Spec
+	new := *c.Spec
+	new.Mon.Count = 5
+	assert.False(t, clusterChanged(old, new, c))
+	assert.Equal(t, 5, mons.Count)
+}
+
 func TestRemoveFinalizer(t *testing.T) {
 	clientset := testop.New(3)
 	context := &clusterd.Context{
@@ -17,25 +17,180 @@ limitations under the License.
 package mon
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
 	"github.com/rook/rook/pkg/clusterd"
 	"github.com/rook/rook/pkg/daemon/ceph/client"
 	cephconfig "github.com/rook/rook/pkg/daemon/ceph/config"
 	mondaemon "github.com/rook/rook/pkg/daemon/ceph/mon"
+	cephver "github.com/rook/rook/pkg/operator/ceph/version"
 	"github.com/rook/rook/pkg/operator/k8sutil"
+	"k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/record"
 )
 
 var (
-	// HealthCheckInterval is the interval to check if the mons are in quorum
+	// HealthCheckInterval is the default interval to check if the mons are in quorum
 	HealthCheckInterval = 45 * time.Second
-	// MonOutTimeout is the duration to wait before removing/failover to a new mon pod
+	// HealthCheckJitter is the fraction of the interval by which each health check is randomly moved earlier
+	// or later, so the health checks of many clusters started together do not all run at the same time
+	HealthCheckJitter = 0.1
+	// MonOutTimeout is the default duration to wait before removing/failover to a new mon pod
 	MonOutTimeout = 300 * time.Second
+	// MaxFailoverSuspension is the longest time the failover of mons can be suspended
+	MaxFailoverSuspension = 24 * time.Hour
+	// RemoveMonRetries is the number of times the removal of a mon from quorum is attempted before giving up
+	RemoveMonRetries = 5
+	// RemoveMonBackoff is the time to wait before the first retry of the removal of a mon. The wait is
+	// doubled for each retry after that.
+	RemoveMonBackoff = 2 * time.Second
+	// ConnectionConfigRetries is the number of times the connection config is written after a mon is removed
+	// before the cluster is marked as degraded
+	ConnectionConfigRetries = 3
+	// ConnectionConfigBackoff is the time to wait between the writes of the connection config
+	ConnectionConfigBackoff = time.Second
+	// QuorumUnreachableInterval is the interval of the health check while the mons cannot be reached at all,
+	// so the health check recovers quickly once the mons are reachable again
+	QuorumUnreachableInterval = 15 * time.Second
+	// FailoverQuorumTimeout is how long to wait for the new mon to join quorum after a mon is failed over
+	FailoverQuorumTimeout = 5 * time.Minute
+	// HealthyMonGracePeriod is the grace period to delete the deployment of a healthy mon, such as an extra
+	// mon after the mon count is reduced or a mon moved to another node, so the mon can flush its store
+	// before it is stopped
+	HealthyMonGracePeriod = 30 * time.Second
+	// DeadMonGracePeriod is the grace period to delete the deployment of a mon that is out of quorum
+	DeadMonGracePeriod = time.Duration(0)
+	// MinMonsInQuorum is the least number of mons that must be left in quorum after a mon is removed, for
+	// any reason. It is a floor that is independent of the desired mon count in the cluster CRD. Zero
+	// disables the floor.
+	MinMonsInQuorum = 0
+	// FailoverBackoff is how long the replacement of a failed mon is not failed over itself. The wait doubles
+	// with each failover of the same mon position, up to MaxFailoverBackoff. Zero disables the backoff.
+	FailoverBackoff = time.Duration(0)
+	// MaxFailoverBackoff is the longest wait between the failovers of a mon position. The failovers of the
+	// position are forgotten once its mon stays in quorum this long.
+	MaxFailoverBackoff = time.Hour
+	// FailedNodeCooldown is how long no new mon is placed on the node of a mon that was failed over, so the
+	// replacement does not land back on a flaky node. Zero disables the cooldown.
+	FailedNodeCooldown = 10 * time.Minute
 )
 
+const (
+	// DefaultMaxConcurrentFailover is the default max number of mons failed over in a single health check
+	DefaultMaxConcurrentFailover = 1
+	// DefaultMaxConcurrentRemoval is the default max number of extra mons removed in a single health check
+	DefaultMaxConcurrentRemoval = 1
+	// DefaultMaxFailoverAttempts is the default number of times a mon and its replacements are failed over
+	// without joining quorum before the failover stops
+	DefaultMaxFailoverAttempts = 3
+
+	// FailoverOrderAddThenRemove starts the replacement of a failed mon before the failed mon is removed
+	FailoverOrderAddThenRemove = "add-then-remove"
+	// FailoverOrderRemoveDeadFirst removes a failed mon before its replacement is started when the mon is
+	// confirmed dead, so the resources of the dead mon are freed for the replacement
+	FailoverOrderRemoveDeadFirst = "remove-dead-first"
+
+	// MonFailoverReason is the reason of the event recorded when a mon is replaced by a new mon
+	MonFailoverReason = "MonFailover"
+	// MonRemovedReason is the reason of the event recorded when a mon is removed from the cluster
+	MonRemovedReason = "MonRemoved"
+	// MonFailoverStoppedReason is the reason of the event recorded when the replacements of a mon keep
+	// failing to join quorum and the mon is no longer failed over
+	MonFailoverStoppedReason = "MonFailoverStopped"
+	// MonMixedVersionsReason is the reason of the event recorded when the mons are found to run mixed versions
+	MonMixedVersionsReason = "MonMixedVersions"
+	// MonQuorumUnreachableReason is the reason of the event recorded when the status of the mons cannot be retrieved
+	MonQuorumUnreachableReason = "MonQuorumUnreachable"
+	// MonConnectionConfigFailedReason is the reason of the event recorded when the connection config cannot
+	// be written after a mon is removed
+	MonConnectionConfigFailedReason = "MonConnectionConfigFailed"
+	// MonCountInvalidReason is the reason of the event recorded when the desired mon count is out of range
+	MonCountInvalidReason = "MonCountInvalid"
+
+	// MonsInQuorumReason is the reason of the MonsHealthy condition when the mons are healthy
+	MonsInQuorumReason = "MonsInQuorum"
+	// MonsOutOfQuorumReason is the reason of the MonsHealthy condition when mons are out of quorum
+	MonsOutOfQuorumReason = "MonsOutOfQuorum"
+	// MonsMissingReason is the reason of the MonsHealthy condition when mons are missing from the mon map
+	MonsMissingReason = "MonsMissing"
+	// MonCountMismatchReason is the reason of the MonsHealthy condition when the count of mons in the mon map
+	// is not the desired count
+	MonCountMismatchReason = "MonCountMismatch"
+
+	// the highest fraction of the health check interval used as jitter
+	maxHealthCheckJitter = 0.5
+
+	// the prefix of the names of the ceph health checks about the mons, such as MON_DOWN
+	monHealthCheckPrefix = "MON_"
+
+	// the output of `ceph mon remove` when the mon is not in the mon map
+	monAlreadyRemovedOutput = "does not exist or has already been removed"
+
+	// the actions of the health check on the mons, as counted in the metrics
+	monActionFailover = "failover"
+	monActionRemove   = "remove"
+	monActionAdd      = "add"
+)
+
+// FailoverReason is the reason the health check failed over or removed a mon, as recorded in the events
+// and the metrics
+type FailoverReason string
+
+const (
+	// FailoverReasonNotInQuorumTimeout is the reason when a mon in the mon map was out of quorum longer than
+	// the mon out timeout
+	FailoverReasonNotInQuorumTimeout FailoverReason = "NotInQuorumTimeout"
+	// FailoverReasonMissingFromMonMap is the reason when an expected mon is not in the mon map
+	FailoverReasonMissingFromMonMap FailoverReason = "MissingFromMonMap"
+	// FailoverReasonExtraMonRemoval is the reason when a mon is removed since more mons are running than desired
+	FailoverReasonExtraMonRemoval FailoverReason = "ExtraMonRemoval"
+	// FailoverReasonPlacement is the reason when a mon is moved to another node, such as when its node is no
+	// longer valid for the mon placement
+	FailoverReasonPlacement FailoverReason = "Placement"
+)
+
+// MonStatusGetter gets the mon status of the cluster for the health check, so the tests can drive the
+// health check with a canned mon status
+type MonStatusGetter interface {
+	GetMonStatus(context *clusterd.Context, clusterName string, debug bool) (client.MonStatusResponse, error)
+}
+
+// cephMonStatus gets the mon status from ceph
+type cephMonStatus struct{}
+
+func (cephMonStatus) GetMonStatus(context *clusterd.Context, clusterName string, debug bool) (client.MonStatusResponse, error) {
+	return client.GetMonStatus(context, clusterName, debug)
+}
+
+// clock gives the current time to the health check, so the tests can move the time past the mon out timeout
+type clock interface {
+	Now() time.Time
+	Since(t time.Time) time.Duration
+}
+
+// realClock is the clock of the system
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) Since(t time.Time) time.Duration {
+	return time.Since(t)
+}
+
 // HealthChecker aggregates the mon/cluster info needed to check the health of the monitors
 type HealthChecker struct {
 	monCluster *Cluster
@@ -48,65 +203,195 @@ func NewHealthChecker(monCluster *Cluster) *HealthChecker {
 	}
 }
 
-// Check periodically checks the health of the monitors
-func (hc *HealthChecker) Check(stopCh chan struct{}) {
+// Check periodically checks the health of the monitors until the context is cancelled
+func (hc *HealthChecker) Check(ctx context.Context) {
+	// the mons loaded from the config map are stale if they changed while the operator was down
+	hc.monCluster.orchestrationMutex.Lock()
+	if err := hc.monCluster.syncMonsWithMonMap(); err != nil {
+		hc.monCluster.log.Warningf("failed to sync the mons with the mon map before the first health check. %+v", err)
+	}
+	hc.monCluster.orchestrationMutex.Unlock()
+
 	for {
 		select {
-		case <-stopCh:
-			logger.Infof("Stopping monitoring of mons in namespace %s", hc.monCluster.Namespace)
+		case <-ctx.Done():
+			hc.monCluster.log.Infof("Stopping monitoring of mons in namespace %s", hc.monCluster.Namespace)
+			removeHealthState(hc.monCluster.Namespace)
 			return
 
-		case <-time.After(HealthCheckInterval):
-			logger.Debugf("checking health of mons")
-			err := hc.monCluster.checkHealth()
+		case <-time.After(jitterInterval(hc.monCluster.getHealthCheckInterval(), HealthCheckJitter, rand.Float64)):
+			hc.monCluster.log.Debugf("checking health of mons")
+			// the mons may be started at the same time for an update of the cluster
+			hc.monCluster.orchestrationMutex.Lock()
+			err := hc.monCluster.checkHealth(ctx)
+			hc.monCluster.orchestrationMutex.Unlock()
 			if err != nil {
-				logger.Infof("failed to check mon health. %+v", err)
+				hc.monCluster.log.Infof("failed to check mon health. %+v", err)
+			}
+		}
+	}
+}
+
+// syncMonsWithMonMap reconciles the mons in the cluster info with the live mon map. A mon only in the mon
+// map, such as a mon added while the operator was down, is added to the cluster info. A mon only in the
+// cluster info is reported and left to the health check, which fails it over if it does not join the map.
+// A dry run only reports the mons it would add.
+func (c *Cluster) syncMonsWithMonMap() error {
+	status, err := c.monStatus.GetMonStatus(c.context, c.clusterInfo.Name, true)
+	if err != nil {
+		return fmt.Errorf("failed to get mon status. %+v", err)
+	}
+	if len(status.MonMap.Mons) == 0 {
+		return fmt.Errorf("the mon map is empty")
+	}
+
+	dryRun := c.isDryRun()
+	added := 0
+	for _, mon := range status.MonMap.Mons {
+		if _, ok := c.clusterInfo.Monitors[mon.Name]; ok {
+			continue
+		}
+		endpoint, err := monEndpointFromAddr(mon.Address)
+		if err != nil {
+			c.log.Warningf("mon %s is in the mon map but not in the cluster info, and cannot be added. %+v", mon.Name, err)
+			continue
+		}
+		if dryRun {
+			c.recordDryRunAction(monActionAdd, "would add mon %s at %s that is in the mon map but not in the cluster info", mon.Name, endpoint)
+			continue
+		}
+		c.log.Warningf("mon %s at %s is in the mon map but not in the cluster info, adding it", mon.Name, endpoint)
+		c.clusterInfo.Monitors[mon.Name] = &cephconfig.MonInfo{Name: mon.Name, Endpoint: endpoint}
+		if id, err := monNameToIndex(mon.Name); err == nil {
+			c.monIDMutex.Lock()
+			if c.maxMonID < id {
+				c.maxMonID = id
 			}
+			c.monIDMutex.Unlock()
+		}
+		added++
+	}
+	for name := range c.clusterInfo.Monitors {
+		if !monInMonMap(name, status) {
+			c.log.Warningf("mon %s is in the cluster info but not in the mon map", name)
 		}
 	}
+	if added == 0 {
+		return nil
+	}
+
+	if err := c.saveMonConfig(); err != nil {
+		return fmt.Errorf("failed to save mon config after adding %d mon(s) from the mon map. %+v", added, err)
+	}
+	if err := writeMonConnectionConfig(c.context, c.clusterInfo); err != nil {
+		return fmt.Errorf("failed to write connection config after adding %d mon(s) from the mon map. %+v", added, err)
+	}
+	return nil
 }
 
-func (c *Cluster) checkHealth() error {
-	logger.Debugf("Checking health for mons (desired=%d). %+v", c.Count, c.clusterInfo)
+func (c *Cluster) checkHealth(ctx context.Context) error {
+	c.log.Debugf("Checking health for mons (desired=%d). %+v", c.Count, c.clusterInfo)
 
 	// Use a local mon count in case the user updates the crd in another goroutine.
 	// We need to complete a health check with a consistent value.
 	c.MonCountMutex.Lock()
 	desiredMonCount := c.Count
 	allowMultiplePerNode := c.AllowMultiplePerNode
+	allowEvenMonCount := c.AllowEvenMonCount
+	monOutTimeout := c.monOutTimeout
+	maxConcurrentFailover := c.maxFailovers
+	maxConcurrentRemoval := c.maxRemovals
+	suspendFailoverUntil := c.suspendFailoverUntil
+	maxFailoverAttempts := c.maxFailoverAttempts
+	pauseOnMixedVersions := c.pauseOnMixedVersions
+	deferOnHealthErr := c.deferOnHealthErr
+	rebalancePreferred := c.rebalancePreferred
+	allowMonRemoval := c.allowMonRemoval
+	cephVersion := c.detectedVersion
 	c.MonCountMutex.Unlock()
 
+	desiredMonCount, err := c.validateMonCount(desiredMonCount, MaxMonCount)
+	if err != nil {
+		return err
+	}
+
+	c.retryStaleConnectionConfig(ctx)
+
+	c.log.Infof("checking health of mons running ceph version %s", cephVersion.String())
+
+	if c.isDryRun() {
+		c.log.Infof("mon health check is in dry-run mode, no mons will be changed")
+	}
+
+	failoverSuspended := c.clock.Now().Before(suspendFailoverUntil)
+	if failoverSuspended {
+		c.log.Infof("mon failover is suspended until %s", suspendFailoverUntil.Format(time.RFC3339))
+	}
+
 	// connect to the mons
 	// get the status and check for quorum
-	status, err := client.GetMonStatus(c.context, c.clusterInfo.Name, true)
+	status, err := c.monStatus.GetMonStatus(c.context, c.clusterInfo.Name, true)
+	if err == nil && len(status.MonMap.Mons) == 0 {
+		// a mon map without mons is not the state of the cluster, such as after a full quorum loss or with
+		// a malformed response. Starting mons would collide with the existing mons that cannot be seen.
+		err = fmt.Errorf("the mon map is empty")
+	}
 	if err != nil {
+		// no mon can be acted on without the status, which is different from a mon out of quorum
+		c.setQuorumUnreachable(err)
+		c.setHealthState(0, desiredMonCount, true)
 		return fmt.Errorf("failed to get mon status. %+v", err)
 	}
-	logger.Debugf("Mon status: %+v", status)
+	c.clearQuorumUnreachable()
+	c.log.Debugf("Mon status: %+v", status)
+	// update the metrics and the health state when done so the out timeouts and failovers of this run are included
+	defer c.updateMetrics(desiredMonCount, status)
+	defer c.setHealthState(len(status.Quorum), desiredMonCount, false)
+
+	// a new mon could be started at the wrong version while the mons are upgraded
+	if c.checkMixedVersions() && pauseOnMixedVersions && !failoverSuspended {
+		c.log.Infof("mon failover is paused while the mons run mixed versions")
+		failoverSuspended = true
+	}
 
-	// Source of truth of which mons should exist is our *clusterInfo*
-	monsNotFound := map[string]interface{}{}
-	for _, mon := range c.clusterInfo.Monitors {
-		monsNotFound[mon.Name] = struct{}{}
+	report := AssessMonHealth(status, c.clusterInfo, c.monTimeoutList, desiredMonCount, monOutTimeout, c.clock.Now())
+	c.updateLastInQuorum(status)
+	c.setMonsHealthy(monsHealthyCondition(report, len(status.MonMap.Mons)))
+
+	// avoid piling mon churn onto a cluster already in HEALTH_ERR for other reasons, unless the quorum is at risk
+	if deferOnHealthErr && !failoverSuspended && (!report.AllInQuorum || len(report.MissingMons) > 0) {
+		if checks := c.nonMonHealthErrors(); len(checks) > 0 {
+			if quorumAtRisk(status) {
+				c.log.Warningf("cluster is in %s (%s), but losing another mon would break quorum. not deferring mon failover", client.CephHealthErr, strings.Join(checks, ","))
+			} else {
+				c.log.Warningf("mon failover is deferred while the cluster is in %s for reasons other than the mons: %s", client.CephHealthErr, strings.Join(checks, ","))
+				failoverSuspended = true
+			}
+		}
 	}
 
 	// first handle mons that are not in quorum but in the ceph mon map
 	// failover the unhealthy mons
-	allMonsInQuorum := true
-	for _, mon := range status.MonMap.Mons {
-		inQuorum := monInQuorum(mon, status.Quorum)
-		// if the mon is in quorum remove it from our check for "existence"
-		// else see below condition
-		if _, ok := monsNotFound[mon.Name]; ok {
-			delete(monsNotFound, mon.Name)
-		} else {
+	failovers := 0
+	monCount := len(status.MonMap.Mons)
+	for _, mon := range report.Mons {
+		if !mon.Expected {
 			// when the mon isn't in the clusterInfo, but is in quorum and there are
 			// enough mons, remove it else remove it on the next run
-			if inQuorum && len(status.MonMap.Mons) > desiredMonCount {
-				logger.Warningf("mon %s not in source of truth but in quorum, removing", mon.Name)
-				c.removeMon(mon.Name)
+			if mon.InQuorum && canSafelyRemoveMon(len(status.MonMap.Mons), desiredMonCount, allowEvenMonCount) {
+				if failoverSuspended {
+					c.log.Warningf("mon %s not in source of truth but in quorum, but the removal of mons is suspended", mon.Name)
+				} else if !allowMonRemoval {
+					c.log.Infof("mon %s not in source of truth but in quorum, but the removal of mons is not allowed. the mon is left running", mon.Name)
+				} else if c.isDryRun() {
+					c.recordDryRunAction(monActionRemove, "would remove mon %s that is not in the source of truth but in quorum", mon.Name)
+				} else {
+					c.log.Warningf("mon %s not in source of truth but in quorum, removing", mon.Name)
+					err := c.removeMon(ctx, mon.Name, HealthyMonGracePeriod, FailoverReasonExtraMonRemoval)
+					c.recordMonAction(monActionRemove, FailoverReasonExtraMonRemoval, err)
+				}
 			} else {
-				logger.Warningf(
+				c.log.Warningf(
 					"mon %s not in source of truth and not in quorum, not enough mons to remove now (wanted: %d, current: %d)",
 					mon.Name,
 					desiredMonCount,
@@ -115,79 +400,780 @@ func (c *Cluster) checkHealth() error {
 			}
 		}
 
-		if inQuorum {
-			logger.Debugf("mon %s found in quorum", mon.Name)
-			// delete the "timeout" for a mon if the pod is in quorum again
-			if _, ok := c.monTimeoutList[mon.Name]; ok {
-				delete(c.monTimeoutList, mon.Name)
-				logger.Infof("mon %s is back in quorum, removed from mon out timeout list", mon.Name)
-			}
-		} else {
-			logger.Debugf("mon %s NOT found in quorum. Mon status: %+v", mon.Name, status)
-			allMonsInQuorum = false
+		c.trackFailoverBackoff(mon.Name, mon.InQuorum)
+		if mon.InQuorum {
+			c.log.Debugf("mon %s found in quorum", mon.Name)
+			// the mon replaced a failed mon successfully
+			delete(c.failoverAttempts, mon.Name)
+			// delete the "timeout" for a mon if the pod is in quorum again
+			if _, ok := c.monTimeoutList[mon.Name]; ok {
+				c.clearMonOutTimeout(mon.Name)
+				c.log.Infof("mon %s is back in quorum, removed from mon out timeout list", mon.Name)
+				c.saveMonTimeouts()
+			}
+		} else {
+			c.log.Debugf("mon %s NOT found in quorum. Mon status: %+v", mon.Name, status)
+
+			// If not yet set, add the current time, for the timeout
+			// calculation, to the list
+			if mon.OutSince.IsZero() {
+				c.startMonOutTimeout(mon.Name, c.clock.Now())
+				c.saveMonTimeouts()
+			}
+
+			// when the timeout for the mon has been reached, continue to the
+			// normal failover/delete mon pod part of the code
+			if !mon.TimedOut {
+				c.log.Warningf("mon %s not found in quorum, still in mon out timeout for %s", mon.Name, mon.TimeUntilFailover)
+				continue
+			}
+
+			if failoverSuspended {
+				c.log.Warningf("mon %s NOT found in quorum and timeout exceeded, but mon failover is suspended", mon.Name)
+				continue
+			}
+
+			if c.failoverStopped(mon.Name, maxFailoverAttempts) {
+				continue
+			}
+
+			if c.failoverBackedOff(mon.Name) {
+				continue
+			}
+
+			// never fail over another mon in the same pass if quorum did not survive the last failover
+			if failovers > 0 && !c.quorumIntact() {
+				c.log.Warningf("quorum not intact after failing over %d mon(s), mon %s will be failed over in a later health check", failovers, mon.Name)
+				return nil
+			}
+
+			c.log.Warningf("mon %s NOT found in quorum and timeout exceeded, mon will be failed over", mon.Name)
+			action, err := c.failMon(ctx, monCount, desiredMonCount, allowEvenMonCount, mon.Name, DeadMonGracePeriod, FailoverReasonNotInQuorumTimeout)
+			if err != nil {
+				return err
+			}
+			if action == monActionRemove {
+				// the mon was removed instead of replaced
+				monCount--
+			}
+			// only deal with a limited number of unhealthy mons per health check
+			failovers++
+			if failovers >= maxConcurrentFailover {
+				return nil
+			}
+		}
+	}
+
+	// after all unhealthy mons have been removed/failovered
+	// handle all mons that haven't been in the Ceph mon map
+	for _, mon := range report.MissingMons {
+		if failovers >= maxConcurrentFailover {
+			return nil
+		}
+		if failoverSuspended {
+			c.log.Warningf("mon %s NOT found in ceph mon map, but mon failover is suspended", mon)
+			continue
+		}
+		if c.failoverStopped(mon, maxFailoverAttempts) {
+			continue
+		}
+		if c.failoverBackedOff(mon) {
+			continue
+		}
+		if failovers > 0 && !c.quorumIntact() {
+			c.log.Warningf("quorum not intact after failing over %d mon(s), mon %s will be failed over in a later health check", failovers, mon)
+			return nil
+		}
+		c.log.Warningf("mon %s NOT found in ceph mon map, failover", mon)
+		if _, err := c.failMon(ctx, len(c.clusterInfo.Monitors), desiredMonCount, allowEvenMonCount, mon, DeadMonGracePeriod, FailoverReasonMissingFromMonMap); err != nil {
+			return err
+		}
+		failovers++
+	}
+
+	// the mon status is stale after a failover, continue with the rest of the checks in the next health check
+	if failovers > 0 {
+		return nil
+	}
+
+	// rebalancing the mons also fails them over
+	if !failoverSuspended {
+		if !allowMultiplePerNode {
+			// check if there are more than two mons running on the same node, failover one mon in that case
+			done, err := c.checkMonsOnSameNode(ctx, desiredMonCount, allowEvenMonCount)
+			if done || err != nil {
+				return err
+			}
+		}
+
+		done, err := c.checkMonsOnValidNodes(ctx)
+		if done || err != nil {
+			return err
+		}
+	}
+
+	// create/start new mons when there are fewer mons than the desired count in the CRD
+	if len(status.MonMap.Mons) < desiredMonCount {
+		if c.isDryRun() {
+			c.recordDryRunAction(monActionAdd, "would add mons. currently %d mons are in quorum and the desired count is %d.", len(status.MonMap.Mons), desiredMonCount)
+			return nil
+		}
+		c.log.Infof("adding mons. currently %d mons are in quorum and the desired count is %d.", len(status.MonMap.Mons), desiredMonCount)
+		return c.startMons()
+	}
+
+	// remove extra mons if the desired count has decreased in the CRD and all the mons are currently healthy
+	if report.AllInQuorum && canSafelyRemoveMon(len(status.MonMap.Mons), desiredMonCount, allowEvenMonCount) {
+		if failoverSuspended {
+			c.log.Infof("%d mons are running and only %d are desired, but the removal of mons is suspended", len(status.MonMap.Mons), desiredMonCount)
+			return nil
+		}
+		if !allowMonRemoval {
+			c.log.Infof("%d mons are running and only %d are desired, but the removal of mons is not allowed. the extra mons are left running", len(status.MonMap.Mons), desiredMonCount)
+			return nil
+		}
+		if c.isDryRun() {
+			c.recordDryRunAction(monActionRemove, "would remove extra mon %s. currently %d are in quorum and only %d are desired", c.extraMonToRemove(status), len(status.MonMap.Mons), desiredMonCount)
+			return nil
+		}
+		return c.removeExtraMons(ctx, desiredMonCount, allowEvenMonCount, maxConcurrentRemoval)
+	}
+
+	// move a mon back to a preferred node only while all the mons are healthy
+	if rebalancePreferred && !failoverSuspended && report.AllInQuorum && len(report.MissingMons) == 0 && len(status.MonMap.Mons) == desiredMonCount {
+		return c.rebalanceToPreferredNode(ctx)
+	}
+
+	return nil
+}
+
+// rebalanceToPreferredNode moves a single mon from a node that is not preferred by the mon placement to a
+// preferred node. The new mon is started before the old mon is removed, like in a failover.
+func (c *Cluster) rebalanceToPreferredNode(ctx context.Context) error {
+	mon, node, ok, err := c.proposeRebalanceMove()
+	if err != nil {
+		return fmt.Errorf("failed to check the mons for preferred nodes. %+v", err)
+	}
+	if !ok {
+		return nil
+	}
+	if c.isDryRun() {
+		c.recordDryRunAction(monActionFailover, "would move mon %s to preferred node %s", mon, node)
+		return nil
+	}
+
+	c.log.Infof("moving mon %s to preferred node %s", mon, node)
+	err = c.failoverMon(ctx, mon, HealthyMonGracePeriod, FailoverReasonPlacement)
+	c.recordMonAction(monActionFailover, FailoverReasonPlacement, err)
+	if err != nil {
+		return fmt.Errorf("failed to move mon %s to a preferred node. %+v", mon, err)
+	}
+	return nil
+}
+
+// proposeRebalanceMove finds a mon on a node that is not preferred by the node affinity of the mon
+// placement, and the node a new mon replacing it would be placed on. The move is only proposed when that
+// node is preferred, so the mons do not move back and forth. Returns false if no move is needed or possible.
+func (c *Cluster) proposeRebalanceMove() (string, string, bool, error) {
+	nodes, err := c.context.Clientset.CoreV1().Nodes().List(metav1.ListOptions{})
+	if err != nil {
+		return "", "", false, err
+	}
+	nodesByName := map[string]v1.Node{}
+	for _, node := range nodes.Items {
+		nodesByName[node.Name] = node
+	}
+
+	mons := []string{}
+	for mon := range c.mapping.Node {
+		mons = append(mons, mon)
+	}
+	sort.Strings(mons)
+	mon := ""
+	for _, name := range mons {
+		node, ok := nodesByName[c.mapping.Node[name].Name]
+		if !ok {
+			continue
+		}
+		preferred, err := k8sutil.PreferredNode(node, c.placement)
+		if err != nil {
+			return "", "", false, err
+		}
+		if !preferred {
+			mon = name
+			break
+		}
+	}
+	if mon == "" {
+		return "", "", false, nil
+	}
+
+	// pick the node the same way as the failover of the mon will
+	available, err := c.getMonNodes()
+	if err != nil {
+		return "", "", false, err
+	}
+	if len(available) == 0 {
+		return "", "", false, nil
+	}
+	domainsInUse, err := c.getFailureDomainsWithMons(mon)
+	if err != nil {
+		return "", "", false, err
+	}
+	target := c.pickMonNode(available, 0, domainsInUse)
+	preferred, err := k8sutil.PreferredNode(target, c.placement)
+	if err != nil || !preferred {
+		return "", "", false, err
+	}
+	return mon, target.Name, true, nil
+}
+
+// MonHealthReport is the assessment of the health of the mons from a mon status
+type MonHealthReport struct {
+	// DesiredCount is the number of mons desired in the cluster CRD
+	DesiredCount int
+	// Mons is the health of each mon of the ceph mon map
+	Mons []MonHealth
+	// MissingMons are the mons of the cluster info, the source of truth of the mons that should exist,
+	// that are not in the ceph mon map
+	MissingMons []string
+	// AllInQuorum is true when all the mons of the ceph mon map are in quorum
+	AllInQuorum bool
+}
+
+// MonHealth is the health of a mon of the ceph mon map
+type MonHealth struct {
+	Name     string
+	InQuorum bool
+	// Expected is true when the mon is in the cluster info
+	Expected bool
+	// OutSince is when the mon was first found out of quorum, or zero if it is not on the timeout list
+	OutSince time.Time
+	// TimedOut is true when the mon has been out of quorum for longer than the mon out timeout
+	TimedOut bool
+	// TimeUntilFailover is how long until a mon out of quorum is failed over. It is the whole timeout for
+	// a mon that is not on the timeout list yet, and zero once the timeout has passed.
+	TimeUntilFailover time.Duration
+}
+
+// AssessMonHealth assesses the health of the mons from their status without acting on it, such as for
+// a diagnostic tool. The timeouts are the times the mons were first found out of quorum.
+func AssessMonHealth(status client.MonStatusResponse, clusterInfo *cephconfig.ClusterInfo, timeouts map[string]time.Time,
+	desired int, outTimeout time.Duration, now time.Time) MonHealthReport {
+
+	report := MonHealthReport{DesiredCount: desired, Mons: []MonHealth{}, MissingMons: []string{}, AllInQuorum: AllMonsInQuorum(status)}
+	inMonMap := map[string]bool{}
+	for _, mon := range status.MonMap.Mons {
+		inMonMap[mon.Name] = true
+		_, expected := clusterInfo.Monitors[mon.Name]
+		health := MonHealth{Name: mon.Name, InQuorum: monInQuorum(mon, status.Quorum), Expected: expected}
+		if !health.InQuorum {
+			health.TimeUntilFailover = outTimeout
+			if outSince, ok := timeouts[mon.Name]; ok {
+				health.OutSince = outSince
+				out := now.Sub(outSince)
+				health.TimedOut = out > outTimeout
+				health.TimeUntilFailover = outTimeout - out
+				if health.TimeUntilFailover < 0 {
+					health.TimeUntilFailover = 0
+				}
+			}
+		}
+		report.Mons = append(report.Mons, health)
+	}
+
+	for name := range clusterInfo.Monitors {
+		if !inMonMap[name] {
+			report.MissingMons = append(report.MissingMons, name)
+		}
+	}
+	sort.Strings(report.MissingMons)
+	return report
+}
+
+// removeExtraMons removes mons one at a time until the desired count or the max number of removals is
+// reached. A mon is only removed while all the mons are in quorum.
+func (c *Cluster) removeExtraMons(ctx context.Context, desiredMonCount int, allowEvenMonCount bool, maxRemovals int) error {
+	for i := 0; i < maxRemovals; i++ {
+		status, err := c.monStatus.GetMonStatus(c.context, c.clusterInfo.Name, true)
+		if err != nil {
+			return fmt.Errorf("failed to get mon status. %+v", err)
+		}
+		if !AllMonsInQuorum(status) {
+			c.log.Infof("%d/%d mons in quorum after removing %d extra mon(s), the next extra mon will be removed in a later health check", len(status.Quorum), len(status.MonMap.Mons), i)
+			return nil
+		}
+		if !canSafelyRemoveMon(len(status.MonMap.Mons), desiredMonCount, allowEvenMonCount) {
+			return nil
+		}
+
+		name := c.extraMonToRemove(status)
+		c.log.Infof("removing extra mon %s. currently %d are in quorum and only %d are desired", name, len(status.MonMap.Mons), desiredMonCount)
+		err = c.removeMon(ctx, name, HealthyMonGracePeriod, FailoverReasonExtraMonRemoval)
+		c.recordMonAction(monActionRemove, FailoverReasonExtraMonRemoval, err)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// extraMonToRemove picks the mon to remove when there are more mons than desired. The time skew of the
+// mons is taken into account when it can be retrieved.
+func (c *Cluster) extraMonToRemove(status client.MonStatusResponse) string {
+	timeStatus, err := client.GetMonTimeStatus(c.context, c.clusterInfo.Name)
+	if err != nil {
+		c.log.Debugf("picking the extra mon to remove without the time skew of the mons. %+v", err)
+		timeStatus = nil
+	}
+	return selectMonToRemove(status, timeStatus)
+}
+
+// selectMonToRemove returns the least healthy mon of the mon map. A mon out of quorum is picked first, then
+// the mon with the highest time skew, then the mon with the highest rank. The mon with the lowest rank in
+// quorum is the leader, so it is the last one picked.
+func selectMonToRemove(status client.MonStatusResponse, timeStatus *client.MonTimeStatus) string {
+	if len(status.MonMap.Mons) == 0 {
+		return ""
+	}
+	selected := status.MonMap.Mons[0]
+	for _, mon := range status.MonMap.Mons[1:] {
+		if monLessHealthy(mon, selected, status.Quorum, timeStatus) {
+			selected = mon
+		}
+	}
+	return selected.Name
+}
+
+// monLessHealthy checks if the first mon is a better candidate for removal than the second mon
+func monLessHealthy(a, b client.MonMapEntry, quorum []int, timeStatus *client.MonTimeStatus) bool {
+	aInQuorum, bInQuorum := monInQuorum(a, quorum), monInQuorum(b, quorum)
+	if aInQuorum != bInQuorum {
+		return !aInQuorum
+	}
+	aSkew, bSkew := monTimeSkew(a.Name, timeStatus), monTimeSkew(b.Name, timeStatus)
+	if aSkew != bSkew {
+		return aSkew > bSkew
+	}
+	return a.Rank > b.Rank
+}
+
+// monTimeSkew returns the absolute time skew of the mon in seconds, or 0 when it is not known
+func monTimeSkew(name string, timeStatus *client.MonTimeStatus) float64 {
+	if timeStatus == nil {
+		return 0
+	}
+	skewStatus, ok := timeStatus.Skew[name]
+	if !ok {
+		return 0
+	}
+	skew, err := skewStatus.Skew.Float64()
+	if err != nil {
+		return 0
+	}
+	return math.Abs(skew)
+}
+
+// UpdateHealthCheck updates the settings of the health check from the spec.
+// Unset or invalid values fall back to the defaults.
+func (c *Cluster) UpdateHealthCheck(spec cephv1.MonHealthCheckSpec) {
+	interval := parseHealthCheckDuration("interval", spec.Interval, HealthCheckInterval)
+	timeout := parseHealthCheckDuration("timeout", spec.Timeout, MonOutTimeout)
+	maxFailover := parseMaxConcurrentFailover(spec.MaxConcurrentFailover)
+	maxRemoval := parseMaxConcurrentRemoval(spec.MaxConcurrentRemoval)
+	suspendUntil := parseSuspendFailoverUntil(spec.SuspendFailoverUntil, c.clock.Now())
+
+	c.MonCountMutex.Lock()
+	defer c.MonCountMutex.Unlock()
+	c.healthCheckInterval = interval
+	c.monOutTimeout = timeout
+	c.maxFailovers = maxFailover
+	c.maxRemovals = maxRemoval
+	c.suspendFailoverUntil = suspendUntil
+	c.dryRun = spec.DryRun
+	c.maxFailoverAttempts = parseMaxFailoverAttempts(spec.MaxFailoverAttempts)
+	c.pauseOnMixedVersions = spec.PauseFailoverOnMixedVersions
+	c.deferOnHealthErr = spec.DeferFailoverOnHealthErr
+	c.rebalancePreferred = spec.RebalanceToPreferredNodes
+	c.allowMonRemoval = parseAllowMonRemoval(spec.AllowMonRemoval)
+	c.failoverOrder = parseFailoverOrder(spec.FailoverOrder)
+}
+
+func (c *Cluster) getHealthCheckInterval() time.Duration {
+	c.MonCountMutex.Lock()
+	defer c.MonCountMutex.Unlock()
+	if c.quorumUnreachable && QuorumUnreachableInterval < c.healthCheckInterval {
+		return QuorumUnreachableInterval
+	}
+	return c.healthCheckInterval
+}
+
+// startMonOutTimeout records the time the mon was first seen out of quorum. The mon out timeouts are only
+// changed by the health check, and are locked for MonFailoverETA.
+func (c *Cluster) startMonOutTimeout(name string, since time.Time) {
+	c.monTimeoutMutex.Lock()
+	defer c.monTimeoutMutex.Unlock()
+	c.monTimeoutList[name] = since
+}
+
+// clearMonOutTimeout forgets the time the mon was first seen out of quorum
+func (c *Cluster) clearMonOutTimeout(name string) {
+	c.monTimeoutMutex.Lock()
+	defer c.monTimeoutMutex.Unlock()
+	delete(c.monTimeoutList, name)
+}
+
+// MonFailoverETA checks if the mon is out of quorum and waiting for the mon out timeout before it is failed
+// over, and how long until the timeout is exceeded. The remaining time is zero once the timeout is
+// exceeded and the mon is failed over by the next health check. It is safe to call from any goroutine.
+func (c *Cluster) MonFailoverETA(name string) (pending bool, remaining time.Duration) {
+	c.MonCountMutex.Lock()
+	monOutTimeout := c.monOutTimeout
+	c.MonCountMutex.Unlock()
+
+	c.monTimeoutMutex.RLock()
+	outSince, ok := c.monTimeoutList[name]
+	c.monTimeoutMutex.RUnlock()
+	if !ok {
+		return false, 0
+	}
+	remaining = outSince.Add(monOutTimeout).Sub(c.clock.Now())
+	if remaining < 0 {
+		remaining = 0
+	}
+	return true, remaining
+}
+
+// updateLastInQuorum records the time of the health check for the mons in quorum. The mons that are no
+// longer in the mon map are forgotten.
+func (c *Cluster) updateLastInQuorum(status client.MonStatusResponse) {
+	now := c.clock.Now()
+	for _, mon := range status.MonMap.Mons {
+		if monInQuorum(mon, status.Quorum) {
+			c.lastInQuorum[mon.Name] = now
+		}
+	}
+	for name := range c.lastInQuorum {
+		if !monInMonMap(name, status) {
+			delete(c.lastInQuorum, name)
+		}
+	}
+}
+
+// setQuorumUnreachable marks the quorum as unreachable when the status of the mons cannot be retrieved,
+// such as during a network partition. The cluster status is only updated the first time.
+func (c *Cluster) setQuorumUnreachable(err error) {
+	c.MonCountMutex.Lock()
+	alreadyUnreachable := c.quorumUnreachable
+	c.quorumUnreachable = true
+	c.MonCountMutex.Unlock()
+	if alreadyUnreachable {
+		c.log.Warningf("mon quorum is still unreachable. %+v", err)
+		return
+	}
+
+	message := fmt.Sprintf("mon quorum is unreachable, the mons are not changed until they can be reached. %+v", err)
+	c.log.Errorf("%s", message)
+	if c.isDryRun() {
+		return
+	}
+	c.recordEvent(v1.EventTypeWarning, MonQuorumUnreachableReason, "%s", message)
+	c.setMonsHealthy(cephv1.ClusterCondition{Status: v1.ConditionFalse, Reason: MonQuorumUnreachableReason, Message: message})
+	if err := c.updateClusterStatus(cephv1.ClusterStateQuorumUnreachable, message); err != nil {
+		c.log.Warningf("failed to mark the mon quorum as unreachable in the cluster status. %+v", err)
+	}
+}
+
+// clearQuorumUnreachable resets the cluster status once the mons can be reached again
+func (c *Cluster) clearQuorumUnreachable() {
+	c.MonCountMutex.Lock()
+	wasUnreachable := c.quorumUnreachable
+	c.quorumUnreachable = false
+	c.MonCountMutex.Unlock()
+	if !wasUnreachable {
+		return
+	}
+
+	c.log.Infof("mon quorum is reachable again")
+	if c.isDryRun() {
+		return
+	}
+	cluster, err := c.context.RookClientset.CephV1().CephClusters(c.Namespace).Get(c.ownerRef.Name, metav1.GetOptions{})
+	if err != nil {
+		c.log.Warningf("failed to get cluster %s to reset its status. %+v", c.ownerRef.Name, err)
+		return
+	}
+	// leave any other state set in the meantime alone
+	if cluster.Status.State != cephv1.ClusterStateQuorumUnreachable {
+		return
+	}
+	if err := c.updateClusterStatus(cephv1.ClusterStateCreated, ""); err != nil {
+		c.log.Warningf("failed to reset the cluster status. %+v", err)
+	}
+}
+
+// jitterInterval moves the interval randomly within the jitter fraction of the interval on either side.
+// The random function returns a number in [0.0, 1.0). The jitter is capped at half the interval.
+func jitterInterval(interval time.Duration, jitter float64, random func() float64) time.Duration {
+	if jitter <= 0 {
+		return interval
+	}
+	if jitter > maxHealthCheckJitter {
+		jitter = maxHealthCheckJitter
+	}
+	offset := (2*random() - 1) * jitter
+	return time.Duration(float64(interval) * (1 + offset))
+}
+
+// MixedVersions checks if the mons ran mixed versions in the last health check
+func (c *Cluster) MixedVersions() bool {
+	c.MonCountMutex.Lock()
+	defer c.MonCountMutex.Unlock()
+	return c.mixedVersions
+}
+
+// checkMixedVersions checks if the mons run mixed versions. An event is recorded when the mons start
+// running mixed versions.
+func (c *Cluster) checkMixedVersions() bool {
+	monVersions, err := client.GetMonVersions(c.context, c.clusterInfo.Name)
+	if err != nil {
+		c.log.Warningf("failed to get the versions of the mons. %+v", err)
+		return false
+	}
+
+	mixed := false
+	if len(monVersions) > 0 {
+		output := []string{}
+		for v := range monVersions {
+			output = append(output, v)
+		}
+		versions, err := cephver.ExtractAllCephVersions(strings.Join(output, "\n"))
+		if err != nil {
+			c.log.Warningf("failed to parse the versions of the mons. %+v", err)
+			return false
+		}
+		mixed = len(versions) > 1
+		if mixed {
+			c.log.Warningf("mons are running mixed versions: %v", monVersions)
+		}
+	}
+
+	c.MonCountMutex.Lock()
+	wasMixed := c.mixedVersions
+	c.mixedVersions = mixed
+	c.MonCountMutex.Unlock()
+	if mixed && !wasMixed {
+		c.recordEvent(v1.EventTypeWarning, MonMixedVersionsReason, "mons are running mixed versions: %v", monVersions)
+	}
+	return mixed
+}
+
+// isDryRun checks if the health check only logs the changes it would make to the mons
+func (c *Cluster) isDryRun() bool {
+	c.MonCountMutex.Lock()
+	defer c.MonCountMutex.Unlock()
+	return c.dryRun
+}
+
+func (c *Cluster) getFailoverOrder() string {
+	c.MonCountMutex.Lock()
+	defer c.MonCountMutex.Unlock()
+	return c.failoverOrder
+}
+
+// parseHealthCheckDuration parses a duration from the health check spec, falling back to the default
+// when the value is not set or not valid
+func parseHealthCheckDuration(name, value string, defaultValue time.Duration) time.Duration {
+	if value == "" {
+		return defaultValue
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		logger.Warningf("invalid mon health check %s %q, using the default of %s. %+v", name, value, defaultValue, err)
+		return defaultValue
+	}
+	return d
+}
+
+// saveMonTimeouts persists the mon out timeouts to the mon config map so they survive an operator restart
+func (c *Cluster) saveMonTimeouts() {
+	if c.isDryRun() {
+		return
+	}
+
+	monTimeouts, err := json.Marshal(c.monTimeoutList)
+	if err != nil {
+		c.log.Warningf("failed to marshal mon timeouts. %+v", err)
+		return
+	}
+
+	cm, err := c.context.Clientset.CoreV1().ConfigMaps(c.Namespace).Get(EndpointConfigMapName, metav1.GetOptions{})
+	if err != nil {
+		c.log.Warningf("failed to get mon config map to save mon timeouts. %+v", err)
+		return
+	}
+	if cm.Data == nil {
+		cm.Data = map[string]string{}
+	}
+	cm.Data[OutTimeoutsKey] = string(monTimeouts)
+	if _, err := c.context.Clientset.CoreV1().ConfigMaps(c.Namespace).Update(cm); err != nil {
+		c.log.Warningf("failed to save mon timeouts. %+v", err)
+	}
+}
+
+// allocateMonID reserves the id of a new mon. The max mon id is saved to the mon config map right away, so
+// neither a failover that fails later on nor a restart of the operator hands out the same id again.
+func (c *Cluster) allocateMonID() int {
+	id := c.nextMonID()
+	if c.isDryRun() {
+		return id
+	}
+
+	cm, err := c.context.Clientset.CoreV1().ConfigMaps(c.Namespace).Get(EndpointConfigMapName, metav1.GetOptions{})
+	if err != nil {
+		c.log.Warningf("failed to get mon config map to save max mon id %d. %+v", id, err)
+		return id
+	}
+	if cm.Data == nil {
+		cm.Data = map[string]string{}
+	}
+	cm.Data[MaxMonIDKey] = strconv.Itoa(id)
+	if _, err := c.context.Clientset.CoreV1().ConfigMaps(c.Namespace).Update(cm); err != nil {
+		c.log.Warningf("failed to save max mon id %d. %+v", id, err)
+	}
+	return id
+}
+
+// parseMaxConcurrentFailover returns the max number of mons to fail over in a single health check,
+// falling back to the default when the value is not set or not valid
+func parseMaxConcurrentFailover(value int) int {
+	if value <= 0 {
+		return DefaultMaxConcurrentFailover
+	}
+	return value
+}
 
-			// If not yet set, add the current time, for the timeout
-			// calculation, to the list
-			if _, ok := c.monTimeoutList[mon.Name]; !ok {
-				c.monTimeoutList[mon.Name] = time.Now()
-			}
+// parseMaxConcurrentRemoval returns the max number of extra mons to remove in a single health check,
+// falling back to the default when the value is not set or not valid
+func parseMaxConcurrentRemoval(value int) int {
+	if value <= 0 {
+		return DefaultMaxConcurrentRemoval
+	}
+	return value
+}
 
-			// when the timeout for the mon has been reached, continue to the
-			// normal failover/delete mon pod part of the code
-			if time.Since(c.monTimeoutList[mon.Name]) <= MonOutTimeout {
-				logger.Warningf("mon %s not found in quorum, still in mon out timeout", mon.Name)
-				continue
-			}
+// parseMaxFailoverAttempts returns the number of times a mon and its replacements are failed over before
+// the failover stops, falling back to the default when the value is not set or not valid
+func parseMaxFailoverAttempts(value int) int {
+	if value <= 0 {
+		return DefaultMaxFailoverAttempts
+	}
+	return value
+}
 
-			logger.Warningf("mon %s NOT found in quorum and timeout exceeded, mon will be failed over", mon.Name)
-			c.failMon(len(status.MonMap.Mons), desiredMonCount, mon.Name)
-			// only deal with one unhealthy mon per health check
-			return nil
-		}
+// parseAllowMonRemoval returns if the extra mons can be removed, which is allowed when it is not set
+func parseAllowMonRemoval(value *bool) bool {
+	return value == nil || *value
+}
+
+// parseFailoverOrder parses the order in which a failed mon is replaced, falling back to add-then-remove
+func parseFailoverOrder(value string) string {
+	switch value {
+	case "":
+		return FailoverOrderAddThenRemove
+	case FailoverOrderAddThenRemove, FailoverOrderRemoveDeadFirst:
+		return value
 	}
+	logger.Warningf("invalid mon failover order %q, using %s", value, FailoverOrderAddThenRemove)
+	return FailoverOrderAddThenRemove
+}
 
-	// after all unhealthy mons have been removed/failovered
-	// handle all mons that haven't been in the Ceph mon map
-	for mon := range monsNotFound {
-		logger.Warningf("mon %s NOT found in ceph mon map, failover", mon)
-		c.failMon(len(c.clusterInfo.Monitors), desiredMonCount, mon)
-		// only deal with one "not found in ceph mon map" mon per health check
-		return nil
+// parseSuspendFailoverUntil parses the time until which the failover of mons is suspended. The suspension
+// is capped at MaxFailoverSuspension from now so a forgotten setting doesn't disable failover for good.
+func parseSuspendFailoverUntil(value string, now time.Time) time.Time {
+	if value == "" {
+		return time.Time{}
+	}
+	until, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		logger.Warningf("invalid time %q to suspend mon failover until, mon failover is not suspended. %+v", value, err)
+		return time.Time{}
 	}
+	if max := now.Add(MaxFailoverSuspension); until.After(max) {
+		logger.Warningf("mon failover cannot be suspended until %s, suspending it for %s instead", value, MaxFailoverSuspension)
+		return max
+	}
+	return until
+}
 
-	if !allowMultiplePerNode {
-		// check if there are more than two mons running on the same node, failover one mon in that case
-		done, err := c.checkMonsOnSameNode(desiredMonCount)
-		if done || err != nil {
-			return err
+// canSafelyRemoveMon checks if one of the current mons can be removed to reach the desired mon count.
+// The mons are never reduced below the desired count or from two mons to one. Since an odd number of
+// mons is recommended, the mons are only reduced to an even desired count if allowEven is set.
+func canSafelyRemoveMon(current, desired int, allowEven bool) bool {
+	if current <= desired {
+		return false
+	}
+	if desired < 1 {
+		logger.Warningf("cannot remove a mon with a desired mon count of %d", desired)
+		return false
+	}
+	if desired%2 == 0 {
+		if !allowEven {
+			logger.Warningf("cannot remove a mon to reach an even mon count of %d, an odd mon count is recommended", desired)
+			return false
 		}
+		logger.Warningf("REDUCING THE MONS TO AN EVEN COUNT OF %d. the mons tolerate no more failures than with %d mons and quorum is at risk. use an odd mon count as soon as possible.", desired, desired-1)
 	}
+	if current == 2 {
+		logger.Warningf("cannot reduce mon quorum size from 2 to 1")
+		return false
+	}
+	return true
+}
 
-	done, err := c.checkMonsOnValidNodes()
-	if done || err != nil {
-		return err
+// quorumIntact checks that a majority of the mons in the mon map are in quorum
+func (c *Cluster) quorumIntact() bool {
+	status, err := c.monStatus.GetMonStatus(c.context, c.clusterInfo.Name, true)
+	if err != nil {
+		c.log.Warningf("failed to get mon status to check quorum. %+v", err)
+		return false
 	}
+	return len(status.Quorum) > len(status.MonMap.Mons)/2
+}
 
-	// create/start new mons when there are fewer mons than the desired count in the CRD
-	if len(status.MonMap.Mons) < desiredMonCount {
-		logger.Infof("adding mons. currently %d mons are in quorum and the desired count is %d.", len(status.MonMap.Mons), desiredMonCount)
-		return c.startMons()
+// quorumAtRisk checks if the quorum would be lost if one more mon left it
+func quorumAtRisk(status client.MonStatusResponse) bool {
+	return len(status.Quorum)-1 <= len(status.MonMap.Mons)/2
+}
+
+// nonMonHealthErrors returns the health checks of the cluster with the HEALTH_ERR severity that are not
+// about the mons. Nothing is returned when the status of the cluster cannot be retrieved.
+func (c *Cluster) nonMonHealthErrors() []string {
+	status, err := client.Status(c.context, c.clusterInfo.Name)
+	if err != nil {
+		c.log.Warningf("failed to get the cluster status to check for health errors. %+v", err)
+		return nil
 	}
+	return filterNonMonHealthErrors(status.Health)
+}
 
-	// remove extra mons if the desired count has decreased in the CRD and all the mons are currently healthy
-	if allMonsInQuorum && len(status.MonMap.Mons) > desiredMonCount {
-		if desiredMonCount < 2 && len(status.MonMap.Mons) == 2 {
-			logger.Warningf("cannot reduce mon quorum size from 2 to 1")
-		} else {
-			logger.Infof("removing an extra mon. currently %d are in quorum and only %d are desired", len(status.MonMap.Mons), desiredMonCount)
-			return c.removeMon(status.MonMap.Mons[0].Name)
+// filterNonMonHealthErrors returns the sorted names of the health checks with the HEALTH_ERR severity
+// that are not about the mons, such as OSD_FULL
+func filterNonMonHealthErrors(health client.HealthStatus) []string {
+	if health.Status != client.CephHealthErr {
+		return nil
+	}
+	checks := []string{}
+	for name, check := range health.Checks {
+		if check.Severity == client.CephHealthErr && !strings.HasPrefix(name, monHealthCheckPrefix) {
+			checks = append(checks, name)
 		}
 	}
-
-	return nil
+	sort.Strings(checks)
+	return checks
 }
 
-func (c *Cluster) checkMonsOnSameNode(desiredMonCount int) (bool, error) {
+func (c *Cluster) checkMonsOnSameNode(ctx context.Context, desiredMonCount int, allowEvenMonCount bool) (bool, error) {
 	nodesUsed := map[string]struct{}{}
 	for name, node := range c.mapping.Node {
 		// when the node is already in the list we have more than one mon on that node
@@ -200,10 +1186,12 @@ func (c *Cluster) checkMonsOnSameNode(desiredMonCount int) (bool, error) {
 			// if there are enough nodes for one mon "that is too much" to be failovered,
 			// fail it over to an other node
 			if len(availableNodes) > 0 {
-				logger.Infof("rebalance: enough nodes available %d to failover mon %s", len(availableNodes), name)
-				c.failMon(len(c.clusterInfo.Monitors), desiredMonCount, name)
+				c.log.Infof("rebalance: enough nodes available %d to failover mon %s", len(availableNodes), name)
+				if _, err := c.failMon(ctx, len(c.clusterInfo.Monitors), desiredMonCount, allowEvenMonCount, name, HealthyMonGracePeriod, FailoverReasonPlacement); err != nil {
+					return true, err
+				}
 			} else {
-				logger.Debugf("rebalance: not enough nodes available to failover mon %s", name)
+				c.log.Debugf("rebalance: not enough nodes available to failover mon %s", name)
 			}
 
 			// deal with one mon too much on a node at a time
@@ -214,7 +1202,7 @@ func (c *Cluster) checkMonsOnSameNode(desiredMonCount int) (bool, error) {
 	return false, nil
 }
 
-func (c *Cluster) checkMonsOnValidNodes() (bool, error) {
+func (c *Cluster) checkMonsOnValidNodes(ctx context.Context) (bool, error) {
 	for mon, nInfo := range c.mapping.Node {
 		// get node to use for validNode() func
 		node, err := c.context.Clientset.CoreV1().Nodes().Get(nInfo.Name, metav1.GetOptions{})
@@ -224,38 +1212,96 @@ func (c *Cluster) checkMonsOnValidNodes() (bool, error) {
 		// check if node the mon is on is still valid
 		valid, err := k8sutil.ValidNode(*node, c.placement)
 		if err != nil {
-			logger.Warning("failed to validate node %s %v", node.Name, err)
+			c.log.Warningf("failed to validate node %s %v", node.Name, err)
 		} else if !valid {
-			logger.Warningf("node %s isn't valid anymore, failover mon %s", nInfo.Name, mon)
-			c.failoverMon(mon)
+			if c.isDryRun() {
+				c.recordDryRunAction(monActionFailover, "would fail over mon %s since node %s isn't valid anymore", mon, nInfo.Name)
+				return true, nil
+			}
+			c.log.Warningf("node %s isn't valid anymore, failover mon %s", nInfo.Name, mon)
+			err := c.failoverMon(ctx, mon, HealthyMonGracePeriod, FailoverReasonPlacement)
+			c.recordMonAction(monActionFailover, FailoverReasonPlacement, err)
+			if err != nil {
+				return true, fmt.Errorf("failed to failover mon %s. %+v", mon, err)
+			}
 			return true, nil
 		}
-		logger.Debugf("node %s with mon %s is still valid", nInfo.Name, mon)
+		c.log.Debugf("node %s with mon %s is still valid", nInfo.Name, mon)
 	}
 	return false, nil
 }
 
-// failMon compares the monCount against desiredMonCount
-func (c *Cluster) failMon(monCount, desiredMonCount int, name string) {
-	if monCount > desiredMonCount {
-		// no need to create a new mon since we have an extra
-		if err := c.removeMon(name); err != nil {
-			logger.Errorf("failed to remove mon %s. %+v", name, err)
+// failMon compares the monCount against desiredMonCount and either removes the mon or replaces it with
+// a new mon. Returns the action taken, monActionRemove or monActionFailover, and whether it failed. The
+// deployment of the mon is deleted with the grace period, which is only zero for a mon that is out of
+// quorum. The reason is the check that decided to act on the mon.
+func (c *Cluster) failMon(ctx context.Context, monCount, desiredMonCount int, allowEvenMonCount bool, name string, gracePeriod time.Duration, reason FailoverReason) (string, error) {
+	remove := canSafelyRemoveMon(monCount, desiredMonCount, allowEvenMonCount)
+	if c.isDryRun() {
+		if remove {
+			c.recordDryRunAction(monActionRemove, "would remove mon %s", name)
+			return monActionRemove, nil
 		}
-	} else {
-		// bring up a new mon to replace the unhealthy mon
-		if err := c.failoverMon(name); err != nil {
-			logger.Errorf("failed to failover mon %s. %+v", name, err)
+		c.recordDryRunAction(monActionFailover, "would fail over mon %s", name)
+		return monActionFailover, nil
+	}
+
+	if remove {
+		// no need to create a new mon since we have an extra
+		err := c.removeMon(ctx, name, gracePeriod, reason)
+		c.recordMonAction(monActionRemove, reason, err)
+		if err != nil {
+			return monActionRemove, fmt.Errorf("failed to remove mon %s. %+v", name, err)
 		}
+		return monActionRemove, nil
+	}
+
+	// bring up a new mon to replace the unhealthy mon
+	err := c.failoverMon(ctx, name, gracePeriod, reason)
+	c.recordMonAction(monActionFailover, reason, err)
+	if err != nil {
+		return monActionFailover, fmt.Errorf("failed to failover mon %s. %+v", name, err)
 	}
+	return monActionFailover, nil
 }
 
-func (c *Cluster) failoverMon(name string) error {
-	logger.Infof("Failing over monitor %s", name)
+// failoverMon replaces the mon with a new mon. The deployment of the old mon is deleted with the grace
+// period, such as HealthyMonGracePeriod for a mon in quorum that is moved to another node. The PreFailover
+// and PostFailover callbacks are invoked around the failover when they are set.
+func (c *Cluster) failoverMon(ctx context.Context, name string, gracePeriod time.Duration, reason FailoverReason) (err error) {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("not failing over mon %s. %+v", name, err)
+	}
+	c.log.Infof("Failing over monitor %s, reason: %s", name, reason)
+
+	c.callPreFailover(name)
+	newName := ""
+	defer func() {
+		c.callPostFailover(name, newName, err)
+	}()
+
+	// count the attempts to replace the original mon in case the new mon does not join quorum either
+	attempts := c.failoverAttempts[name]
+	failovers := 0
+	if backoff, ok := c.failoverBackoff[name]; ok {
+		failovers = backoff.failovers
+	}
+	failedNode, hasNode := c.mapping.Node[name]
+
+	// a dead mon is removed before its replacement is started when the failover order allows it, since a
+	// mon that is only out of quorum may still rejoin while the replacement starts
+	removeFirst := c.getFailoverOrder() == FailoverOrderRemoveDeadFirst && c.monConfirmedDead(name)
+	if removeFirst {
+		c.log.Infof("removing dead mon %s before starting its replacement", name)
+		if err := c.removeMon(ctx, name, DeadMonGracePeriod, reason); err != nil {
+			return err
+		}
+	}
 
 	// Start a new monitor
-	m := newMonConfig(c.maxMonID + 1)
-	logger.Infof("starting new mon: %+v", m)
+	m := newMonConfig(c.allocateMonID())
+	newName = m.DaemonName
+	c.log.Infof("starting new mon: %+v", m)
 
 	// Create the service endpoint
 	serviceIP, err := c.createService(m)
@@ -265,8 +1311,13 @@ func (c *Cluster) failoverMon(name string) error {
 
 	mConf := []*monConfig{m}
 
+	// keep the replacement off the node of the failed mon for a while
+	if hasNode {
+		c.excludeNode(failedNode.Name, FailedNodeCooldown)
+	}
+
 	// Assign the pod to a node
-	if err = c.assignMons(mConf); err != nil {
+	if err = c.assignMons(mConf, name); err != nil {
 		return fmt.Errorf("failed to place new mon on a node. %+v", err)
 	}
 
@@ -282,38 +1333,228 @@ func (c *Cluster) failoverMon(name string) error {
 	c.clusterInfo.Monitors[m.DaemonName] = cephconfig.NewMonInfo(m.DaemonName, m.PublicIP, m.Port)
 
 	// Start the deployment
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("not starting new mon %s. %+v", m.DaemonName, err)
+	}
 	if err = c.startDeployments(mConf, len(mConf)-1); err != nil {
 		return fmt.Errorf("failed to start new mon %s. %+v", m.DaemonName, err)
 	}
 
-	// Only increment the max mon id if the new pod started successfully
-	c.maxMonID++
+	if !removeFirst {
+		if err := c.removeMon(ctx, name, gracePeriod, reason); err != nil {
+			return err
+		}
+	}
+	c.failoverAttempts[m.DaemonName] = attempts + 1
+	c.setFailoverBackoff(m.DaemonName, failovers+1)
+	c.recordEvent(v1.EventTypeNormal, MonFailoverReason, "failed over mon %s to new mon %s, reason: %s", name, m.DaemonName, reason)
+
+	// wait for the new mon to join quorum before acting on the mons again
+	if c.waitForStart {
+		if err := client.WaitForMonInQuorum(ctx, c.context, c.clusterInfo.Name, m.DaemonName, FailoverQuorumTimeout); err != nil {
+			return fmt.Errorf("failed to wait for quorum after the failover of mon %s. %+v", name, err)
+		}
+	}
+	return nil
+}
+
+// callPreFailover invokes the PreFailover callback. A panic of the callback is logged and does not stop
+// the failover.
+func (c *Cluster) callPreFailover(name string) {
+	if c.PreFailover == nil {
+		return
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			c.log.Errorf("pre-failover callback of mon %s failed. %+v", name, r)
+		}
+	}()
+	c.PreFailover(name)
+}
+
+// callPostFailover invokes the PostFailover callback. A panic of the callback is logged and does not
+// change the result of the failover.
+func (c *Cluster) callPostFailover(oldName, newName string, err error) {
+	if c.PostFailover == nil {
+		return
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			c.log.Errorf("post-failover callback of mon %s failed. %+v", oldName, r)
+		}
+	}()
+	c.PostFailover(oldName, newName, err)
+}
+
+// monConfirmedDead checks if the mon cannot come back on its own since its deployment is gone, or the node
+// the mon is pinned to is gone or cordoned. The mon is not confirmed dead if its state cannot be retrieved.
+func (c *Cluster) monConfirmedDead(name string) bool {
+	_, err := c.context.Clientset.Extensions().Deployments(c.Namespace).Get(resourceName(name), metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			c.log.Infof("deployment of mon %s is gone", name)
+			return true
+		}
+		c.log.Warningf("failed to get the deployment of mon %s. %+v", name, err)
+		return false
+	}
+
+	nodeInfo, ok := c.mapping.Node[name]
+	if !ok {
+		return false
+	}
+	node, err := c.context.Clientset.CoreV1().Nodes().Get(nodeInfo.Name, metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			c.log.Infof("node %s of mon %s is gone", nodeInfo.Name, name)
+			return true
+		}
+		c.log.Warningf("failed to get node %s of mon %s. %+v", nodeInfo.Name, name, err)
+		return false
+	}
+	if node.Spec.Unschedulable {
+		c.log.Infof("node %s of mon %s is cordoned", nodeInfo.Name, name)
+		return true
+	}
+	return false
+}
+
+// checkMinMonsInQuorum refuses the removal of the mon if fewer than min mons would be left in quorum
+func (c *Cluster) checkMinMonsInQuorum(name string, min int) error {
+	if min <= 0 {
+		return nil
+	}
+	status, err := c.monStatus.GetMonStatus(c.context, c.clusterInfo.Name, true)
+	if err != nil {
+		return fmt.Errorf("not removing mon %s, failed to get mon status to check the min of %d mons in quorum. %+v", name, min, err)
+	}
+	remaining := len(status.Quorum)
+	for _, mon := range status.MonMap.Mons {
+		if mon.Name == name && monInQuorum(mon, status.Quorum) {
+			remaining--
+		}
+	}
+	if remaining < min {
+		return fmt.Errorf("not removing mon %s, it would leave %d mons in quorum and at least %d are required", name, remaining, min)
+	}
+	return nil
+}
+
+// writeMonConnectionConfig writes the connection config of the mons, replaced in the tests to fail the write
+var writeMonConnectionConfig = writeConnectionConfig
+
+// writeConnectionConfigWithRetries writes the connection config of the mons, retrying a failed write
+func (c *Cluster) writeConnectionConfigWithRetries(ctx context.Context) error {
+	var err error
+	for i := 0; i < ConnectionConfigRetries; i++ {
+		if i > 0 {
+			select {
+			case <-ctx.Done():
+				return fmt.Errorf("stopped retrying the write of the connection config. %+v", ctx.Err())
+			case <-time.After(ConnectionConfigBackoff):
+			}
+		}
+		if err = writeMonConnectionConfig(c.context, c.clusterInfo); err == nil {
+			return nil
+		}
+		c.log.Warningf("failed to write connection config. %+v", err)
+	}
+	return err
+}
+
+// setConnectionConfigStale marks the cluster as degraded when the connection config still refers to a
+// removed mon. The write is retried by the next health checks.
+func (c *Cluster) setConnectionConfigStale(message string) {
+	c.MonCountMutex.Lock()
+	c.connConfigMessage = message
+	c.MonCountMutex.Unlock()
+
+	c.log.Warningf("%s. the write is retried in the next health check", message)
+	c.recordEvent(v1.EventTypeWarning, MonConnectionConfigFailedReason, "%s", message)
+	if err := c.updateClusterStatus(cephv1.ClusterStateDegraded, message); err != nil {
+		c.log.Warningf("failed to mark the cluster as degraded. %+v", err)
+	}
+}
+
+// retryStaleConnectionConfig writes the connection config again if it failed after a mon was removed, and
+// resets the cluster status once the write succeeds
+func (c *Cluster) retryStaleConnectionConfig(ctx context.Context) {
+	c.MonCountMutex.Lock()
+	message := c.connConfigMessage
+	c.MonCountMutex.Unlock()
+	if message == "" {
+		return
+	}
+
+	if err := c.writeConnectionConfigWithRetries(ctx); err != nil {
+		c.log.Warningf("connection config is still stale. %+v", err)
+		return
+	}
+	c.MonCountMutex.Lock()
+	c.connConfigMessage = ""
+	c.MonCountMutex.Unlock()
+	c.log.Infof("wrote the stale connection config")
+
+	cluster, err := c.context.RookClientset.CephV1().CephClusters(c.Namespace).Get(c.ownerRef.Name, metav1.GetOptions{})
+	if err != nil {
+		c.log.Warningf("failed to get cluster %s to reset its status. %+v", c.ownerRef.Name, err)
+		return
+	}
+	// leave any other state set in the meantime alone
+	if cluster.Status.State != cephv1.ClusterStateDegraded || cluster.Status.Message != message {
+		return
+	}
+	if err := c.updateClusterStatus(cephv1.ClusterStateCreated, ""); err != nil {
+		c.log.Warningf("failed to reset the cluster status. %+v", err)
+	}
+}
 
-	return c.removeMon(name)
+// deleteMonDeployment deletes the deployment of a mon, replaced in the tests to inspect the delete options
+var deleteMonDeployment = func(clientset kubernetes.Interface, namespace, name string, options *metav1.DeleteOptions) error {
+	return clientset.Extensions().Deployments(namespace).Delete(name, options)
 }
 
-func (c *Cluster) removeMon(daemonName string) error {
-	logger.Infof("ensuring removal of unhealthy monitor %s", daemonName)
+// removeMon removes the mon from the cluster. The deployment of the mon is deleted with the grace period,
+// which can be zero for a mon that is already dead. The reason is recorded in the event of the removal.
+func (c *Cluster) removeMon(ctx context.Context, daemonName string, gracePeriod time.Duration, reason FailoverReason) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("not removing mon %s. %+v", daemonName, err)
+	}
+	if err := c.checkMinMonsInQuorum(daemonName, MinMonsInQuorum); err != nil {
+		return err
+	}
+	c.log.Infof("ensuring removal of unhealthy monitor %s", daemonName)
+
+	// since nautilus, ceph can check that enough mons are left to form quorum before the mon is stopped
+	if c.CephVersion().AtLeastNautilus() {
+		if err := okToRemoveMonitor(c.context, c.clusterInfo.Name, daemonName); err != nil {
+			return err
+		}
+	}
 
 	resourceName := resourceName(daemonName)
 
 	// Remove the mon pod if it is still there
-	var gracePeriod int64
+	gracePeriodSeconds := int64(gracePeriod / time.Second)
 	propagation := metav1.DeletePropagationForeground
-	options := &metav1.DeleteOptions{GracePeriodSeconds: &gracePeriod, PropagationPolicy: &propagation}
-	if err := c.context.Clientset.Extensions().Deployments(c.Namespace).Delete(resourceName, options); err != nil {
+	options := &metav1.DeleteOptions{GracePeriodSeconds: &gracePeriodSeconds, PropagationPolicy: &propagation}
+	if err := deleteMonDeployment(c.context.Clientset, c.Namespace, resourceName, options); err != nil {
 		if errors.IsNotFound(err) {
-			logger.Infof("dead mon %s was already gone", resourceName)
+			c.log.Infof("dead mon %s was already gone", resourceName)
 		} else {
 			return fmt.Errorf("failed to remove dead mon deployment %s. %+v", resourceName, err)
 		}
 	}
 
-	// Remove the bad monitor from quorum
-	if err := removeMonitorFromQuorum(c.context, c.clusterInfo.Name, daemonName); err != nil {
+	// Remove the bad monitor from quorum. The mon is only removed from the cluster info and its service
+	// deleted after ceph confirms the mon is gone from the mon map, or the mon could be stranded.
+	if err := c.removeMonitorFromQuorumAndConfirm(ctx, daemonName); err != nil {
 		return fmt.Errorf("failed to remove mon %s from quorum. %+v", daemonName, err)
 	}
 	delete(c.clusterInfo.Monitors, daemonName)
+	c.clearMonOutTimeout(daemonName)
+	delete(c.failoverAttempts, daemonName)
+	delete(c.failoverBackoff, daemonName)
 	// check if a mapping exists for the mon
 	if _, ok := c.mapping.Node[daemonName]; ok {
 		nodeName := c.mapping.Node[daemonName].Name
@@ -331,34 +1572,354 @@ func (c *Cluster) removeMon(daemonName string) error {
 		}
 	}
 
-	// Remove the service endpoint
+	if err := c.saveMonConfig(); err != nil {
+		return fmt.Errorf("failed to save mon config after failing over mon %s. %+v", daemonName, err)
+	}
+
+	// make sure to rewrite the config so NO new connections are made to the removed mon. The mon is already
+	// gone, so a failed write does not fail the removal and the write is retried in the next health check.
+	if err := c.writeConnectionConfigWithRetries(ctx); err != nil {
+		c.setConnectionConfigStale(fmt.Sprintf("failed to write connection config after removing mon %s. %+v", daemonName, err))
+	}
+
+	// Remove the service endpoint once no config refers to the mon anymore
 	if err := c.context.Clientset.CoreV1().Services(c.Namespace).Delete(resourceName, options); err != nil {
 		if errors.IsNotFound(err) {
-			logger.Infof("dead mon service %s was already gone", resourceName)
+			c.log.Infof("dead mon service %s was already gone", resourceName)
 		} else {
 			return fmt.Errorf("failed to remove dead mon service %s. %+v", resourceName, err)
 		}
 	}
 
-	if err := c.saveMonConfig(); err != nil {
-		return fmt.Errorf("failed to save mon config after failing over mon %s. %+v", daemonName, err)
+	c.recordEvent(v1.EventTypeNormal, MonRemovedReason, "removed mon %s, reason: %s", daemonName, reason)
+	return nil
+}
+
+// SetEventRecorder sets the recorder for the events about the mons of the cluster
+func (c *Cluster) SetEventRecorder(recorder record.EventRecorder) {
+	c.recorder = recorder
+}
+
+// recordEvent records an event against the cluster that owns the mons. Events are best effort and
+// are skipped when no recorder is set.
+func (c *Cluster) recordEvent(eventType, reason, messageFmt string, args ...interface{}) {
+	if c.recorder == nil {
+		return
+	}
+	ref := &v1.ObjectReference{
+		APIVersion: c.ownerRef.APIVersion,
+		Kind:       c.ownerRef.Kind,
+		Name:       c.ownerRef.Name,
+		UID:        c.ownerRef.UID,
+		Namespace:  c.Namespace,
+	}
+	c.recorder.Eventf(ref, eventType, reason, messageFmt, args...)
+}
+
+// failoverStopped checks if the mon is the replacement of a mon that was already failed over too many
+// times without joining quorum. In that case the cluster is marked as degraded instead of replacing
+// the mon again.
+func (c *Cluster) failoverStopped(name string, maxAttempts int) bool {
+	attempts := c.failoverAttempts[name]
+	if attempts < maxAttempts {
+		return false
+	}
+
+	message := fmt.Sprintf("mon %s did not join quorum after %d failover attempts. mon failover is stopped until the mon joins quorum or the operator is restarted", name, attempts)
+	c.log.Errorf("%s", message)
+	if c.isDryRun() {
+		return true
+	}
+	c.recordEvent(v1.EventTypeWarning, MonFailoverStoppedReason, "%s", message)
+	if err := c.updateClusterStatus(cephv1.ClusterStateDegraded, message); err != nil {
+		c.log.Warningf("failed to mark the cluster as degraded. %+v", err)
+	}
+	return true
+}
+
+// monFailoverBackoff paces the failovers of a mon position, which is carried from a failed mon to its
+// replacement
+type monFailoverBackoff struct {
+	// the number of times the position was failed over
+	failovers int
+	// the mon is not failed over before this time
+	until time.Time
+	// when the mon was last found in quorum after being out of quorum, zero if it is out of quorum
+	healthySince time.Time
+}
+
+// failoverDelay returns the wait before the next failover of a mon position that was already failed over
+// the given number of times. The base delay doubles with each failover, up to the max.
+func failoverDelay(failovers int, base, max time.Duration) time.Duration {
+	if failovers <= 0 || base <= 0 {
+		return 0
+	}
+	if max < base {
+		max = base
+	}
+	delay := base
+	for i := 1; i < failovers; i++ {
+		delay *= 2
+		if delay >= max {
+			return max
+		}
+	}
+	return delay
+}
+
+// setFailoverBackoff starts the backoff of the new mon that replaced a failed mon
+func (c *Cluster) setFailoverBackoff(name string, failovers int) {
+	delay := failoverDelay(failovers, FailoverBackoff, MaxFailoverBackoff)
+	if delay == 0 {
+		return
+	}
+	c.failoverBackoff[name] = &monFailoverBackoff{failovers: failovers, until: c.clock.Now().Add(delay)}
+	c.log.Infof("mon %s is not failed over for %s after %d failover(s) of its position", name, delay, failovers)
+}
+
+// failoverBackedOff checks if the mon replaced a failed mon too recently to be failed over itself
+func (c *Cluster) failoverBackedOff(name string) bool {
+	backoff, ok := c.failoverBackoff[name]
+	if !ok || !c.clock.Now().Before(backoff.until) {
+		return false
+	}
+	c.log.Warningf("mon %s will not be failed over for another %s after %d failover(s) of its position", name, backoff.until.Sub(c.clock.Now()), backoff.failovers)
+	return true
+}
+
+// trackFailoverBackoff resets the backoff of a mon position once its mon stays in quorum for the max backoff
+func (c *Cluster) trackFailoverBackoff(name string, inQuorum bool) {
+	backoff, ok := c.failoverBackoff[name]
+	if !ok {
+		return
+	}
+	if !inQuorum {
+		backoff.healthySince = time.Time{}
+		return
+	}
+	now := c.clock.Now()
+	if backoff.healthySince.IsZero() {
+		backoff.healthySince = now
+		return
+	}
+	if now.Sub(backoff.healthySince) >= MaxFailoverBackoff {
+		c.log.Infof("mon %s stayed in quorum for %s, reset the failover backoff of its position", name, MaxFailoverBackoff)
+		delete(c.failoverBackoff, name)
+	}
+}
+
+// validateMonCount checks that the desired mon count is within [1, maxMons]. A count below one is refused
+// and the mons are left as they are, while a count above the max is lowered to the max. The reason is
+// recorded in an event and in the status of the cluster CRD the first time the count is found invalid.
+func (c *Cluster) validateMonCount(count, maxMons int) (int, error) {
+	var message string
+	valid := count
+	if count < 1 {
+		message = fmt.Sprintf("mon count %d is not valid, at least one mon is required. the mons are not changed until the count is fixed", count)
+		valid = 0
+	} else if count > maxMons {
+		message = fmt.Sprintf("mon count %d is above the max of %d, running %d mons instead", count, maxMons, maxMons)
+		valid = maxMons
+	}
+
+	if message != "" && message != c.monCountMessage {
+		c.log.Warningf("%s", message)
+		if !c.isDryRun() {
+			c.recordEvent(v1.EventTypeWarning, MonCountInvalidReason, "%s", message)
+			if err := c.updateClusterStatus(cephv1.ClusterStateError, message); err != nil {
+				c.log.Warningf("failed to update the cluster status with the invalid mon count. %+v", err)
+			}
+		}
+	}
+	c.monCountMessage = message
+
+	if valid == 0 {
+		c.setMonsHealthy(cephv1.ClusterCondition{Status: v1.ConditionFalse, Reason: MonCountInvalidReason, Message: message})
+		return 0, fmt.Errorf("%s", message)
+	}
+	return valid, nil
+}
+
+// updateClusterStatus updates the status of the cluster CRD the mons belong to
+func (c *Cluster) updateClusterStatus(state cephv1.ClusterState, message string) error {
+	cluster, err := c.context.RookClientset.CephV1().CephClusters(c.Namespace).Get(c.ownerRef.Name, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get cluster %s prior to updating its status. %+v", c.ownerRef.Name, err)
+	}
+
+	// keep the conditions of the cluster
+	cluster.Status.State = state
+	cluster.Status.Message = message
+	if _, err := c.context.RookClientset.CephV1().CephClusters(c.Namespace).Update(cluster); err != nil {
+		return fmt.Errorf("failed to update cluster %s status. %+v", c.ownerRef.Name, err)
+	}
+	return nil
+}
+
+// monsHealthyCondition returns the MonsHealthy condition for the health of the mons in the report and the
+// count of mons in the mon map. The mons are healthy when the desired count is running and all are in quorum.
+func monsHealthyCondition(report MonHealthReport, monCount int) cephv1.ClusterCondition {
+	condition := cephv1.ClusterCondition{Status: v1.ConditionFalse}
+	var outOfQuorum []string
+	for _, mon := range report.Mons {
+		if !mon.InQuorum {
+			outOfQuorum = append(outOfQuorum, mon.Name)
+		}
+	}
+
+	switch {
+	case len(outOfQuorum) > 0:
+		condition.Reason = MonsOutOfQuorumReason
+		condition.Message = fmt.Sprintf("mons %s are out of quorum", strings.Join(outOfQuorum, ","))
+	case len(report.MissingMons) > 0:
+		condition.Reason = MonsMissingReason
+		condition.Message = fmt.Sprintf("mons %s are missing from the mon map", strings.Join(report.MissingMons, ","))
+	case monCount != report.DesiredCount:
+		condition.Reason = MonCountMismatchReason
+		condition.Message = fmt.Sprintf("%d mons are in the mon map and %d are desired", monCount, report.DesiredCount)
+	default:
+		condition.Status = v1.ConditionTrue
+		condition.Reason = MonsInQuorumReason
+		condition.Message = fmt.Sprintf("all %d mons are in quorum", monCount)
+	}
+	return condition
+}
+
+// setMonsHealthy sets the MonsHealthy condition in the status of the cluster CRD. The CRD is only updated
+// when the condition changed. Like the events without a recorder, the condition is skipped when there is
+// no rook clientset. A dry run leaves the condition alone.
+func (c *Cluster) setMonsHealthy(condition cephv1.ClusterCondition) {
+	condition.Type = cephv1.ClusterConditionMonsHealthy
+	if c.context.RookClientset == nil || condition == c.monsCondition || c.isDryRun() {
+		return
+	}
+
+	cluster, err := c.context.RookClientset.CephV1().CephClusters(c.Namespace).Get(c.ownerRef.Name, metav1.GetOptions{})
+	if err != nil {
+		c.log.Warningf("failed to get cluster %s to set the %s condition. %+v", c.ownerRef.Name, condition.Type, err)
+		return
+	}
+	conditions, changed := setClusterCondition(cluster.Status.Conditions, condition, c.clock.Now())
+	if changed {
+		cluster.Status.Conditions = conditions
+		if _, err := c.context.RookClientset.CephV1().CephClusters(c.Namespace).Update(cluster); err != nil {
+			c.log.Warningf("failed to set the %s condition on cluster %s. %+v", condition.Type, c.ownerRef.Name, err)
+			return
+		}
+		c.log.Infof("%s condition is %s: %s", condition.Type, condition.Status, condition.Message)
+	}
+	c.monsCondition = condition
+}
+
+// setClusterCondition sets the condition in the list of conditions, replacing any condition of the same type.
+// The transition time is only moved when the status of the condition changes. Returns whether the list changed.
+func setClusterCondition(conditions []cephv1.ClusterCondition, condition cephv1.ClusterCondition, now time.Time) ([]cephv1.ClusterCondition, bool) {
+	condition.LastTransitionTime = metav1.NewTime(now)
+	for i, existing := range conditions {
+		if existing.Type != condition.Type {
+			continue
+		}
+		if existing.Status == condition.Status {
+			if existing.Reason == condition.Reason && existing.Message == condition.Message {
+				return conditions, false
+			}
+			condition.LastTransitionTime = existing.LastTransitionTime
+		}
+		conditions[i] = condition
+		return conditions, true
+	}
+	return append(conditions, condition), true
+}
+
+// SetCephVersion sets the version detected on the ceph image of the cluster
+func (c *Cluster) SetCephVersion(v cephver.CephVersion) {
+	c.MonCountMutex.Lock()
+	defer c.MonCountMutex.Unlock()
+	c.detectedVersion = v
+}
+
+// CephVersion returns the version detected on the ceph image of the cluster, as seen by the health check
+func (c *Cluster) CephVersion() cephver.CephVersion {
+	c.MonCountMutex.Lock()
+	defer c.MonCountMutex.Unlock()
+	return c.detectedVersion
+}
+
+// removeMonitorFromQuorumAndConfirm removes the mon from quorum and confirms that the mon is no longer
+// in the mon map. The removal is retried a limited number of times with an exponential backoff, unless
+// the context is cancelled.
+func (c *Cluster) removeMonitorFromQuorumAndConfirm(ctx context.Context, name string) error {
+	var err error
+	backoff := RemoveMonBackoff
+	for i := 0; i < RemoveMonRetries; i++ {
+		if i > 0 {
+			c.log.Infof("retrying the removal of mon %s in %s", name, backoff)
+			select {
+			case <-ctx.Done():
+				return fmt.Errorf("stopped retrying the removal of mon %s. %+v", name, ctx.Err())
+			case <-time.After(backoff):
+			}
+			backoff *= 2
+		}
+
+		if err = c.removeMonitorFromQuorum(name); err != nil {
+			c.log.Warningf("failed to remove mon %s from quorum. %+v", name, err)
+			continue
+		}
+
+		var status client.MonStatusResponse
+		status, err = c.monStatus.GetMonStatus(c.context, c.clusterInfo.Name, true)
+		if err != nil {
+			c.log.Warningf("failed to confirm the removal of mon %s. %+v", name, err)
+			continue
+		}
+		if !monInMonMap(name, status) {
+			return nil
+		}
+		err = fmt.Errorf("mon %s is still in the mon map", name)
+		c.log.Warningf("%+v", err)
 	}
 
-	// make sure to rewrite the config so NO new connections are made to the removed mon
-	if err := writeConnectionConfig(c.context, c.clusterInfo); err != nil {
-		return fmt.Errorf("failed to write connection config after failing over mon %s. %+v", daemonName, err)
+	return fmt.Errorf("failed to remove mon %s after %d attempts. %+v", name, RemoveMonRetries, err)
+}
+
+// monInMonMap checks if a mon with the given name is in the mon map
+func monInMonMap(name string, status client.MonStatusResponse) bool {
+	for _, mon := range status.MonMap.Mons {
+		if mon.Name == name {
+			return true
+		}
 	}
+	return false
+}
 
+// okToRemoveMonitor checks with `ceph mon ok-to-rm`, which is available since nautilus, that removing
+// the mon leaves enough mons to form quorum
+func okToRemoveMonitor(context *clusterd.Context, clusterName, name string) error {
+	args := []string{"mon", "ok-to-rm", name}
+	if output, err := client.ExecuteCephCommand(context, clusterName, args); err != nil {
+		if strings.Contains(string(output), monAlreadyRemovedOutput) {
+			return nil
+		}
+		return fmt.Errorf("mon %s is not safe to remove. %s. %+v", name, string(output), err)
+	}
 	return nil
 }
 
-func removeMonitorFromQuorum(context *clusterd.Context, clusterName, name string) error {
-	logger.Debugf("removing monitor %s", name)
+func (c *Cluster) removeMonitorFromQuorum(name string) error {
+	c.log.Debugf("removing monitor %s", name)
+	// the same command is used by all the supported versions. removing a mon by name also removes its msgr2
+	// addresses since nautilus, and the nautilus check that the mon is safe to remove is done by removeMon
+	// before the mon is stopped.
 	args := []string{"mon", "remove", name}
-	if _, err := client.ExecuteCephCommand(context, clusterName, args); err != nil {
-		return fmt.Errorf("mon %s remove failed: %+v", name, err)
+	if output, err := client.ExecuteCephCommand(c.context, c.clusterInfo.Name, args); err != nil {
+		if !strings.Contains(string(output), monAlreadyRemovedOutput) {
+			return fmt.Errorf("mon %s remove failed: %+v", name, err)
+		}
+		c.log.Infof("monitor %s was already removed", name)
+		return nil
 	}
 
-	logger.Infof("removed monitor %s", name)
+	c.log.Infof("removed monitor %s", name)
 	return nil
 }
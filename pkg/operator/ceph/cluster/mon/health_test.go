@@ -17,18 +17,25 @@ limitations under the License.
 package mon
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"math/rand"
 	"os"
+	"strings"
 	"testing"
+	"time"
 
 	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
 	rookalpha "github.com/rook/rook/pkg/apis/rook.io/v1alpha2"
+	rookfake "github.com/rook/rook/pkg/client/clientset/versioned/fake"
 	"github.com/rook/rook/pkg/clusterd"
 	"github.com/rook/rook/pkg/daemon/ceph/client"
 	clienttest "github.com/rook/rook/pkg/daemon/ceph/client/test"
+	cephconfig "github.com/rook/rook/pkg/daemon/ceph/config"
 	mondaemon "github.com/rook/rook/pkg/daemon/ceph/mon"
+	cephver "github.com/rook/rook/pkg/operator/ceph/version"
 	testopk8s "github.com/rook/rook/pkg/operator/k8sutil/test"
 	"github.com/rook/rook/pkg/operator/test"
 	exectest "github.com/rook/rook/pkg/util/exec/test"
@@ -37,9 +44,16 @@ import (
 	"k8s.io/api/extensions/v1beta1"
 	extensions "k8s.io/api/extensions/v1beta1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	k8stesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/kubernetes/pkg/kubelet/apis"
 )
 
+// ctx is the context of the health checks in the tests, where the clusterd contexts are named context
+var ctx = context.Background()
+
 func TestCheckHealth(t *testing.T) {
 	var deploymentsUpdated *[]*extensions.Deployment
 	updateDeploymentAndWait, deploymentsUpdated = testopk8s.UpdateDeploymentAndWaitStub()
@@ -71,19 +85,28 @@ func TestCheckHealth(t *testing.T) {
 	c.mapping.Port["node0"] = mondaemon.DefaultPort
 	c.maxMonID = 4
 
-	err := c.checkHealth()
+	err := c.checkHealth(ctx)
 	assert.Nil(t, err)
 	logger.Infof("mons after checkHealth: %v", c.clusterInfo.Monitors)
 	// No updates in unit tests w/ workaround
 	assert.ElementsMatch(t, []string{}, testopk8s.DeploymentNamesUpdated(deploymentsUpdated))
 	testopk8s.ClearDeploymentsUpdated(deploymentsUpdated)
 
-	err = c.failoverMon("f")
+	recorder := record.NewFakeRecorder(10)
+	c.SetEventRecorder(recorder)
+	err = c.failoverMon(ctx, "f", DeadMonGracePeriod, FailoverReasonNotInQuorumTimeout)
 	assert.Nil(t, err)
 	// No updates in unit tests w/ workaround
 	assert.ElementsMatch(t, []string{}, testopk8s.DeploymentNamesUpdated(deploymentsUpdated))
 	testopk8s.ClearDeploymentsUpdated(deploymentsUpdated)
 
+	// the removal of the old mon and the failover are both recorded
+	assert.Equal(t, 2, len(recorder.Events))
+	assert.Equal(t, "Normal MonRemoved removed mon f, reason: NotInQuorumTimeout", <-recorder.Events)
+	assert.Equal(t, "Normal MonFailover failed over mon f to new mon g, reason: NotInQuorumTimeout", <-recorder.Events)
+	// the node of the failed mon is excluded from new mons for a while
+	assert.Contains(t, c.excludedNodes, "node0")
+
 	newMons := []string{
 		"g",
 	}
@@ -136,7 +159,7 @@ func TestCheckHealthNotFound(t *testing.T) {
 
 	// Because the mon a isn't in the MonInQuorumResponse() this will create a new mon
 	delete(c.mapping.Node, "b")
-	err = c.checkHealth()
+	err = c.checkHealth(ctx)
 	assert.Nil(t, err)
 	// No updates in unit tests w/ workaround
 	assert.ElementsMatch(t, []string{}, testopk8s.DeploymentNamesUpdated(deploymentsUpdated))
@@ -215,7 +238,7 @@ func TestCheckHealthTwoMonsOneNode(t *testing.T) {
 	}
 
 	// initial health check should already see that there is more than one mon on one node (node0)
-	_, err := c.checkMonsOnSameNode(3)
+	_, err := c.checkMonsOnSameNode(ctx, 3, false)
 	assert.Nil(t, err)
 	assert.Equal(t, "node0", c.mapping.Node["a"].Name)
 	assert.Equal(t, "node0", c.mapping.Node["b"].Name)
@@ -241,7 +264,7 @@ func TestCheckHealthTwoMonsOneNode(t *testing.T) {
 	n.Name = "node2"
 	clientset.CoreV1().Nodes().Create(n)
 
-	_, err = c.checkMonsOnSameNode(3)
+	_, err = c.checkMonsOnSameNode(ctx, 3, false)
 	assert.Nil(t, err)
 
 	// check that mon c exists
@@ -263,7 +286,7 @@ func TestCheckHealthTwoMonsOneNode(t *testing.T) {
 
 	// enable different ceph mon map output
 	executorNextMons = true
-	_, err = c.checkMonsOnSameNode(3)
+	_, err = c.checkMonsOnSameNode(ctx, 3, false)
 	assert.Nil(t, err)
 
 	// check that nothing has changed
@@ -280,11 +303,9 @@ func TestCheckHealthTwoMonsOneNode(t *testing.T) {
 }
 
 func TestCheckMonsValid(t *testing.T) {
-	executor := &exectest.MockExecutor{
-		MockExecuteCommandWithOutputFile: func(debug bool, actionName string, command string, outFileArg string, args ...string) (string, error) {
-			return clienttest.MonInQuorumResponse(), nil
-		},
-	}
+	status := &client.MonStatusResponse{}
+	json.Unmarshal([]byte(clienttest.MonInQuorumResponse()), status)
+	executor := newMonStatusExecutor(status, nil)
 	clientset := test.New(1)
 	configDir, _ := ioutil.TempDir("", "")
 	defer os.RemoveAll(configDir)
@@ -331,7 +352,7 @@ func TestCheckMonsValid(t *testing.T) {
 		clientset.CoreV1().Nodes().Create(n)
 	}
 
-	_, err := c.checkMonsOnValidNodes()
+	_, err := c.checkMonsOnValidNodes(ctx)
 	assert.Nil(t, err)
 	assert.Equal(t, "node0", c.mapping.Node["a"].Name)
 	assert.Equal(t, "node1", c.mapping.Node["b"].Name)
@@ -351,7 +372,7 @@ func TestCheckMonsValid(t *testing.T) {
 		assert.Nil(t, err)
 	}
 
-	_, err = c.checkMonsOnValidNodes()
+	_, err = c.checkMonsOnValidNodes(ctx)
 	assert.Nil(t, err)
 
 	assert.Len(t, c.mapping.Node, 2)
@@ -367,12 +388,9 @@ func TestAddRemoveMons(t *testing.T) {
 	var deploymentsUpdated *[]*extensions.Deployment
 	updateDeploymentAndWait, deploymentsUpdated = testopk8s.UpdateDeploymentAndWaitStub()
 
-	monQuorumResponse := clienttest.MonInQuorumResponse()
-	executor := &exectest.MockExecutor{
-		MockExecuteCommandWithOutputFile: func(debug bool, actionName string, command string, outFileArg string, args ...string) (string, error) {
-			return monQuorumResponse, nil
-		},
-	}
+	status := &client.MonStatusResponse{}
+	json.Unmarshal([]byte(clienttest.MonInQuorumResponse()), status)
+	executor := newMonStatusExecutor(status, nil)
 	clientset := test.New(1)
 	configDir, _ := ioutil.TempDir("", "")
 	defer os.RemoveAll(configDir)
@@ -389,7 +407,7 @@ func TestAddRemoveMons(t *testing.T) {
 	defer os.RemoveAll(c.context.ConfigDir)
 
 	// checking the health will increase the mons as desired all in one go
-	err := c.checkHealth()
+	err := c.checkHealth(ctx)
 	assert.Nil(t, err)
 	assert.Equal(t, 5, len(c.clusterInfo.Monitors), fmt.Sprintf("mons: %v", c.clusterInfo.Monitors))
 	// No updates in unit tests w/ workaround
@@ -397,9 +415,9 @@ func TestAddRemoveMons(t *testing.T) {
 	testopk8s.ClearDeploymentsUpdated(deploymentsUpdated)
 
 	// reducing the mon count to 3 will reduce the mon count once each time we call checkHealth
-	monQuorumResponse = clienttest.MonInQuorumResponseFromMons(c.clusterInfo.Monitors)
+	json.Unmarshal([]byte(clienttest.MonInQuorumResponseFromMons(c.clusterInfo.Monitors)), status)
 	c.Count = 3
-	err = c.checkHealth()
+	err = c.checkHealth(ctx)
 	assert.Nil(t, err)
 	assert.Equal(t, 4, len(c.clusterInfo.Monitors))
 	// No updates in unit tests w/ workaround
@@ -407,8 +425,8 @@ func TestAddRemoveMons(t *testing.T) {
 	testopk8s.ClearDeploymentsUpdated(deploymentsUpdated)
 
 	// after the second call we will be down to the expected count of 3
-	monQuorumResponse = clienttest.MonInQuorumResponseFromMons(c.clusterInfo.Monitors)
-	err = c.checkHealth()
+	json.Unmarshal([]byte(clienttest.MonInQuorumResponseFromMons(c.clusterInfo.Monitors)), status)
+	err = c.checkHealth(ctx)
 	assert.Nil(t, err)
 	assert.Equal(t, 3, len(c.clusterInfo.Monitors))
 	// No updates in unit tests w/ workaround
@@ -416,9 +434,9 @@ func TestAddRemoveMons(t *testing.T) {
 	testopk8s.ClearDeploymentsUpdated(deploymentsUpdated)
 
 	// now attempt to reduce the mons down to quorum size 1
-	monQuorumResponse = clienttest.MonInQuorumResponseFromMons(c.clusterInfo.Monitors)
+	json.Unmarshal([]byte(clienttest.MonInQuorumResponseFromMons(c.clusterInfo.Monitors)), status)
 	c.Count = 1
-	err = c.checkHealth()
+	err = c.checkHealth(ctx)
 	assert.Nil(t, err)
 	assert.Equal(t, 2, len(c.clusterInfo.Monitors))
 	// No updates in unit tests w/ workaround
@@ -426,11 +444,2230 @@ func TestAddRemoveMons(t *testing.T) {
 	testopk8s.ClearDeploymentsUpdated(deploymentsUpdated)
 
 	// cannot reduce from quorum size of 2 to 1
-	monQuorumResponse = clienttest.MonInQuorumResponseFromMons(c.clusterInfo.Monitors)
-	err = c.checkHealth()
+	json.Unmarshal([]byte(clienttest.MonInQuorumResponseFromMons(c.clusterInfo.Monitors)), status)
+	err = c.checkHealth(ctx)
 	assert.Nil(t, err)
 	assert.Equal(t, 2, len(c.clusterInfo.Monitors))
 	// No updates in unit tests w/ workaround
 	assert.ElementsMatch(t, []string{}, testopk8s.DeploymentNamesUpdated(deploymentsUpdated))
 	testopk8s.ClearDeploymentsUpdated(deploymentsUpdated)
 }
+
+func TestAssessMonHealth(t *testing.T) {
+	now := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	clusterInfo := test.CreateConfigDir(3)
+	status := client.MonStatusResponse{Quorum: []int{0, 1, 2}}
+	status.MonMap.Mons = []client.MonMapEntry{
+		{Name: "a", Rank: 0, Address: "1.2.3.1"},
+		{Name: "b", Rank: 1, Address: "1.2.3.2"},
+		{Name: "c", Rank: 2, Address: "1.2.3.3"},
+	}
+
+	// all the mons are in quorum
+	report := AssessMonHealth(status, clusterInfo, map[string]time.Time{}, 3, MonOutTimeout, now)
+	assert.Equal(t, 3, report.DesiredCount)
+	assert.True(t, report.AllInQuorum)
+	assert.Equal(t, 0, len(report.MissingMons))
+	assert.Equal(t, 3, len(report.Mons))
+	for _, mon := range report.Mons {
+		assert.True(t, mon.InQuorum)
+		assert.True(t, mon.Expected)
+		assert.False(t, mon.TimedOut)
+		assert.Equal(t, time.Duration(0), mon.TimeUntilFailover)
+	}
+
+	// mon b just dropped out of quorum and mon c has been out for a while
+	status.Quorum = []int{0}
+	timeouts := map[string]time.Time{"c": now.Add(-time.Minute)}
+	report = AssessMonHealth(status, clusterInfo, timeouts, 3, MonOutTimeout, now)
+	assert.False(t, report.AllInQuorum)
+	assert.Equal(t, MonHealth{Name: "b", Expected: true, TimeUntilFailover: MonOutTimeout}, report.Mons[1])
+	assert.Equal(t, MonHealth{Name: "c", Expected: true, OutSince: now.Add(-time.Minute), TimeUntilFailover: MonOutTimeout - time.Minute}, report.Mons[2])
+
+	// mon c is past the timeout
+	timeouts["c"] = now.Add(-MonOutTimeout - time.Second)
+	report = AssessMonHealth(status, clusterInfo, timeouts, 3, MonOutTimeout, now)
+	assert.True(t, report.Mons[2].TimedOut)
+	assert.Equal(t, time.Duration(0), report.Mons[2].TimeUntilFailover)
+
+	// mon a is missing from the mon map and mon d is not in the cluster info
+	status.Quorum = []int{1, 2, 3}
+	status.MonMap.Mons = []client.MonMapEntry{
+		{Name: "b", Rank: 1, Address: "1.2.3.2"},
+		{Name: "c", Rank: 2, Address: "1.2.3.3"},
+		{Name: "d", Rank: 3, Address: "1.2.3.4"},
+	}
+	report = AssessMonHealth(status, clusterInfo, map[string]time.Time{}, 3, MonOutTimeout, now)
+	assert.True(t, report.AllInQuorum)
+	assert.Equal(t, []string{"a"}, report.MissingMons)
+	assert.Equal(t, "d", report.Mons[2].Name)
+	assert.False(t, report.Mons[2].Expected)
+
+	// the inputs are not changed
+	assert.Equal(t, 3, len(clusterInfo.Monitors))
+	assert.Equal(t, 1, len(timeouts))
+}
+
+func TestRemoveExtraMonsInOnePass(t *testing.T) {
+	newFiveMonCluster := func(status *client.MonStatusResponse, desired, maxRemovals int, removed func(name string)) *Cluster {
+		configDir, _ := ioutil.TempDir("", "")
+		context := &clusterd.Context{
+			Clientset: test.New(3),
+			ConfigDir: configDir,
+			Executor:  newMonStatusExecutor(status, removed),
+		}
+		monSpec := cephv1.MonSpec{Count: desired, AllowMultiplePerNode: true, HealthCheck: cephv1.MonHealthCheckSpec{MaxConcurrentRemoval: maxRemovals}}
+		c := New(context, "ns", "", "myversion", cephv1.CephVersionSpec{}, monSpec,
+			rookalpha.Placement{}, false, v1.ResourceRequirements{}, metav1.OwnerReference{})
+		c.clusterInfo = test.CreateConfigDir(5)
+		c.waitForStart = false
+		c.maxMonID = 4
+		json.Unmarshal([]byte(clienttest.MonInQuorumResponseFromMons(c.clusterInfo.Monitors)), status)
+		return c
+	}
+
+	// 5 to 3 mons in a single health check
+	c := newFiveMonCluster(&client.MonStatusResponse{}, 3, 2, nil)
+	defer os.RemoveAll(c.context.ConfigDir)
+	err := c.checkHealth(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(c.clusterInfo.Monitors))
+
+	// no more than the max number of mons are removed
+	c = newFiveMonCluster(&client.MonStatusResponse{}, 1, 2, nil)
+	defer os.RemoveAll(c.context.ConfigDir)
+	err = c.checkHealth(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(c.clusterInfo.Monitors))
+
+	// the removal stops when a mon drops out of quorum
+	status := &client.MonStatusResponse{}
+	c = newFiveMonCluster(status, 3, 2, func(name string) {
+		status.Quorum = status.Quorum[1:]
+	})
+	defer os.RemoveAll(c.context.ConfigDir)
+	err = c.checkHealth(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, 4, len(c.clusterInfo.Monitors))
+}
+
+func TestCheckHealthAllowMonRemoval(t *testing.T) {
+	status := &client.MonStatusResponse{}
+	configDir, _ := ioutil.TempDir("", "")
+	defer os.RemoveAll(configDir)
+	removed := []string{}
+	context := &clusterd.Context{
+		Clientset: test.New(3),
+		ConfigDir: configDir,
+		Executor:  newMonStatusExecutor(status, func(name string) { removed = append(removed, name) }),
+	}
+	allow := false
+	monSpec := cephv1.MonSpec{Count: 3, AllowMultiplePerNode: true, HealthCheck: cephv1.MonHealthCheckSpec{AllowMonRemoval: &allow}}
+	c := New(context, "ns", "", "myversion", cephv1.CephVersionSpec{}, monSpec,
+		rookalpha.Placement{}, false, v1.ResourceRequirements{}, metav1.OwnerReference{})
+	c.clusterInfo = test.CreateConfigDir(4)
+	c.waitForStart = false
+	c.maxMonID = 3
+	json.Unmarshal([]byte(clienttest.MonInQuorumResponseFromMons(c.clusterInfo.Monitors)), status)
+
+	// the extra mon is left running
+	err := c.checkHealth(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, 4, len(c.clusterInfo.Monitors))
+	assert.Equal(t, 0, len(removed))
+
+	// the extra mon is removed once the removal is allowed again
+	c.UpdateHealthCheck(cephv1.MonHealthCheckSpec{})
+	err = c.checkHealth(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(c.clusterInfo.Monitors))
+	assert.Equal(t, 1, len(removed))
+}
+
+func TestCheckHealthAllowMonRemovalUnexpectedMon(t *testing.T) {
+	// mon e is in quorum but not in the source of truth
+	status := &client.MonStatusResponse{Quorum: []int{0, 1, 2, 3}}
+	status.MonMap.Mons = []client.MonMapEntry{
+		{Name: "a", Rank: 0, Address: "1.2.3.1"},
+		{Name: "b", Rank: 1, Address: "1.2.3.2"},
+		{Name: "c", Rank: 2, Address: "1.2.3.3"},
+		{Name: "e", Rank: 3, Address: "1.2.3.5"},
+	}
+	configDir, _ := ioutil.TempDir("", "")
+	defer os.RemoveAll(configDir)
+	removed := []string{}
+	clientset := test.New(3)
+	d := &extensions.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "rook-ceph-mon-e", Namespace: "ns"}}
+	_, err := clientset.Extensions().Deployments("ns").Create(d)
+	assert.Nil(t, err)
+	context := &clusterd.Context{
+		Clientset: clientset,
+		ConfigDir: configDir,
+		Executor:  newMonStatusExecutor(status, func(name string) { removed = append(removed, name) }),
+	}
+	allow := false
+	monSpec := cephv1.MonSpec{Count: 3, AllowMultiplePerNode: true, HealthCheck: cephv1.MonHealthCheckSpec{AllowMonRemoval: &allow}}
+	c := New(context, "ns", "", "myversion", cephv1.CephVersionSpec{}, monSpec,
+		rookalpha.Placement{}, false, v1.ResourceRequirements{}, metav1.OwnerReference{})
+	c.clusterInfo = test.CreateConfigDir(3)
+	c.waitForStart = false
+	c.maxMonID = 4
+
+	// the unexpected mon is left running
+	err = c.checkHealth(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(removed))
+	_, err = clientset.Extensions().Deployments("ns").Get("rook-ceph-mon-e", metav1.GetOptions{})
+	assert.Nil(t, err)
+
+	// the unexpected mon is removed once the removal is allowed again
+	c.UpdateHealthCheck(cephv1.MonHealthCheckSpec{})
+	err = c.checkHealth(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"e"}, removed)
+}
+
+func TestJitterInterval(t *testing.T) {
+	fixed := func(r float64) func() float64 { return func() float64 { return r } }
+
+	// the ends and the middle of the jitter band
+	assert.Equal(t, 90*time.Second, jitterInterval(100*time.Second, 0.1, fixed(0)))
+	assert.Equal(t, 100*time.Second, jitterInterval(100*time.Second, 0.1, fixed(0.5)))
+	assert.Equal(t, 105*time.Second, jitterInterval(100*time.Second, 0.1, fixed(0.75)))
+
+	// no jitter
+	assert.Equal(t, 100*time.Second, jitterInterval(100*time.Second, 0, fixed(0)))
+	assert.Equal(t, 100*time.Second, jitterInterval(100*time.Second, -1, fixed(0)))
+
+	// the jitter is capped
+	assert.Equal(t, 50*time.Second, jitterInterval(100*time.Second, 2, fixed(0)))
+
+	// successive waits vary within the band
+	r := rand.New(rand.NewSource(1))
+	waits := map[time.Duration]bool{}
+	for i := 0; i < 100; i++ {
+		wait := jitterInterval(HealthCheckInterval, 0.1, r.Float64)
+		assert.True(t, wait >= HealthCheckInterval*9/10, fmt.Sprintf("wait %s", wait))
+		assert.True(t, wait <= HealthCheckInterval*11/10, fmt.Sprintf("wait %s", wait))
+		waits[wait] = true
+	}
+	assert.True(t, len(waits) > 1)
+}
+
+func TestSelectMonToRemove(t *testing.T) {
+	status := client.MonStatusResponse{Quorum: []int{0, 1, 2, 3}}
+	status.MonMap.Mons = []client.MonMapEntry{
+		{Name: "a", Rank: 0, Address: "1.2.3.1"},
+		{Name: "b", Rank: 1, Address: "1.2.3.2"},
+		{Name: "c", Rank: 2, Address: "1.2.3.3"},
+		{Name: "d", Rank: 3, Address: "1.2.3.4"},
+	}
+
+	// the leader with the lowest rank is not picked when all the mons are healthy
+	assert.Equal(t, "d", selectMonToRemove(status, nil))
+	assert.Equal(t, "d", selectMonToRemove(status, &client.MonTimeStatus{}))
+
+	// the laggy mon is picked
+	timeStatus := &client.MonTimeStatus{Skew: map[string]client.MonTimeSkewStatus{
+		"a": {Skew: json.Number("0.000000"), Health: "HEALTH_OK"},
+		"b": {Skew: json.Number("-0.512000"), Health: "HEALTH_WARN"},
+		"c": {Skew: json.Number("0.012000"), Health: "HEALTH_OK"},
+	}}
+	assert.Equal(t, "b", selectMonToRemove(status, timeStatus))
+
+	// a mon out of quorum is picked before the laggy mon
+	status.Quorum = []int{0, 1, 3}
+	assert.Equal(t, "c", selectMonToRemove(status, timeStatus))
+
+	// no mons
+	assert.Equal(t, "", selectMonToRemove(client.MonStatusResponse{}, nil))
+}
+
+func TestCheckHealthCustomTimeout(t *testing.T) {
+	// mon c is in the mon map but out of quorum
+	resp := client.MonStatusResponse{Quorum: []int{0, 1}}
+	resp.MonMap.Mons = []client.MonMapEntry{
+		{Name: "a", Rank: 0, Address: "1.2.3.1"},
+		{Name: "b", Rank: 1, Address: "1.2.3.2"},
+		{Name: "c", Rank: 2, Address: "1.2.3.3"},
+	}
+	executor := newMonStatusExecutor(&resp, nil)
+	clientset := test.New(3)
+	configDir, _ := ioutil.TempDir("", "")
+	defer os.RemoveAll(configDir)
+	context := &clusterd.Context{
+		Clientset: clientset,
+		ConfigDir: configDir,
+		Executor:  executor,
+	}
+	monSpec := cephv1.MonSpec{Count: 3, AllowMultiplePerNode: true, HealthCheck: cephv1.MonHealthCheckSpec{Interval: "10s", Timeout: "600s"}}
+	c := New(context, "ns", "", "myversion", cephv1.CephVersionSpec{}, monSpec,
+		rookalpha.Placement{}, false, v1.ResourceRequirements{}, metav1.OwnerReference{})
+	c.clusterInfo = test.CreateConfigDir(3)
+	c.waitForStart = false
+	c.maxMonID = 2
+	assert.Equal(t, 10*time.Second, c.getHealthCheckInterval())
+	assert.Equal(t, 600*time.Second, c.monOutTimeout)
+
+	// mon c has been out of quorum longer than the default timeout, but not the custom one
+	c.monTimeoutList["c"] = time.Now().Add(-400 * time.Second)
+	err := c.checkHealth(ctx)
+	assert.Nil(t, err)
+	assert.NotNil(t, c.clusterInfo.Monitors["c"])
+	assert.Nil(t, c.clusterInfo.Monitors["d"])
+
+	// falling back to the default timeout fails over mon c
+	c.UpdateHealthCheck(cephv1.MonHealthCheckSpec{})
+	assert.Equal(t, HealthCheckInterval, c.getHealthCheckInterval())
+	assert.Equal(t, MonOutTimeout, c.monOutTimeout)
+	err = c.checkHealth(ctx)
+	assert.Nil(t, err)
+	assert.Nil(t, c.clusterInfo.Monitors["c"])
+	assert.NotNil(t, c.clusterInfo.Monitors["d"])
+}
+
+// fakeClock is a clock that only moves when the test advances it
+type fakeClock struct {
+	now time.Time
+}
+
+func (f *fakeClock) Now() time.Time {
+	return f.now
+}
+
+func (f *fakeClock) Since(t time.Time) time.Duration {
+	return f.now.Sub(t)
+}
+
+func TestCheckHealthOutTimeoutWithClock(t *testing.T) {
+	// mon c is in the mon map but out of quorum
+	resp := client.MonStatusResponse{Quorum: []int{0, 1}}
+	resp.MonMap.Mons = []client.MonMapEntry{
+		{Name: "a", Rank: 0, Address: "1.2.3.1"},
+		{Name: "b", Rank: 1, Address: "1.2.3.2"},
+		{Name: "c", Rank: 2, Address: "1.2.3.3"},
+	}
+	configDir, _ := ioutil.TempDir("", "")
+	defer os.RemoveAll(configDir)
+	context := &clusterd.Context{
+		Clientset: test.New(3),
+		ConfigDir: configDir,
+		Executor:  newMonStatusExecutor(&resp, nil),
+	}
+	c := New(context, "ns", "", "myversion", cephv1.CephVersionSpec{}, cephv1.MonSpec{Count: 3, AllowMultiplePerNode: true},
+		rookalpha.Placement{}, false, v1.ResourceRequirements{}, metav1.OwnerReference{})
+	c.clusterInfo = test.CreateConfigDir(3)
+	c.waitForStart = false
+	c.maxMonID = 2
+	clock := &fakeClock{now: time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)}
+	c.clock = clock
+
+	// the out timeout starts when mon c is first found out of quorum
+	err := c.checkHealth(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, clock.now, c.monTimeoutList["c"])
+
+	// mon c is not failed over at the timeout
+	clock.now = clock.now.Add(MonOutTimeout)
+	err = c.checkHealth(ctx)
+	assert.Nil(t, err)
+	assert.NotNil(t, c.clusterInfo.Monitors["c"])
+	assert.Nil(t, c.clusterInfo.Monitors["d"])
+
+	// mon c is failed over past the timeout
+	clock.now = clock.now.Add(time.Second)
+	err = c.checkHealth(ctx)
+	assert.Nil(t, err)
+	assert.Nil(t, c.clusterInfo.Monitors["c"])
+	assert.NotNil(t, c.clusterInfo.Monitors["d"])
+}
+
+func TestFailoverMonIDsAfterRestart(t *testing.T) {
+	// mon c is in the mon map but out of quorum
+	status := &client.MonStatusResponse{Quorum: []int{0, 1}}
+	status.MonMap.Mons = []client.MonMapEntry{
+		{Name: "a", Rank: 0, Address: "1.2.3.1"},
+		{Name: "b", Rank: 1, Address: "1.2.3.2"},
+		{Name: "c", Rank: 2, Address: "1.2.3.3"},
+	}
+	configDir, _ := ioutil.TempDir("", "")
+	defer os.RemoveAll(configDir)
+	clientset := test.New(3)
+	context := &clusterd.Context{
+		Clientset: clientset,
+		ConfigDir: configDir,
+		Executor:  newMonStatusExecutor(status, nil),
+	}
+	newCephCluster := func() *Cluster {
+		c := New(context, "ns", "", "myversion", cephv1.CephVersionSpec{}, cephv1.MonSpec{Count: 3, AllowMultiplePerNode: true},
+			rookalpha.Placement{}, false, v1.ResourceRequirements{}, metav1.OwnerReference{})
+		c.clusterInfo = test.CreateConfigDir(3)
+		c.waitForStart = false
+		return c
+	}
+
+	c := newCephCluster()
+	c.maxMonID = 2
+	c.monTimeoutList["c"] = time.Now().Add(-2 * MonOutTimeout)
+	err := c.checkHealth(ctx)
+	assert.Nil(t, err)
+	assert.Nil(t, c.clusterInfo.Monitors["c"])
+	assert.NotNil(t, c.clusterInfo.Monitors["d"])
+	cm, err := clientset.CoreV1().ConfigMaps("ns").Get(EndpointConfigMapName, metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, "3", cm.Data[MaxMonIDKey])
+
+	// the operator restarts and mon d goes out of quorum as well
+	c = newCephCluster()
+	c.clusterInfo.Monitors, c.maxMonID, c.mapping, err = loadMonConfig(clientset, "ns")
+	assert.Nil(t, err)
+	assert.Equal(t, 3, c.maxMonID)
+	status.MonMap.Mons = append(status.MonMap.Mons, client.MonMapEntry{Name: "d", Rank: 2, Address: "1.2.3.4"})
+	c.monTimeoutList["d"] = time.Now().Add(-2 * MonOutTimeout)
+	err = c.checkHealth(ctx)
+	assert.Nil(t, err)
+	assert.Nil(t, c.clusterInfo.Monitors["d"])
+	assert.NotNil(t, c.clusterInfo.Monitors["e"])
+	assert.Equal(t, 3, len(c.clusterInfo.Monitors))
+
+	// without the saved max mon id, the max is derived from the names of the mons
+	cm, err = clientset.CoreV1().ConfigMaps("ns").Get(EndpointConfigMapName, metav1.GetOptions{})
+	assert.Nil(t, err)
+	delete(cm.Data, MaxMonIDKey)
+	_, err = clientset.CoreV1().ConfigMaps("ns").Update(cm)
+	assert.Nil(t, err)
+	_, maxMonID, _, err := loadMonConfig(clientset, "ns")
+	assert.Nil(t, err)
+	assert.Equal(t, 4, maxMonID)
+
+	// an id is not handed out again when the failover fails after the id is allocated
+	assert.Equal(t, 5, c.allocateMonID())
+	assert.Equal(t, 6, c.allocateMonID())
+	_, maxMonID, _, err = loadMonConfig(clientset, "ns")
+	assert.Nil(t, err)
+	assert.Equal(t, 6, maxMonID)
+}
+
+func TestParseHealthCheckDuration(t *testing.T) {
+	assert.Equal(t, 30*time.Second, parseHealthCheckDuration("interval", "", 30*time.Second))
+	assert.Equal(t, 90*time.Second, parseHealthCheckDuration("interval", "90s", 30*time.Second))
+	assert.Equal(t, 10*time.Minute, parseHealthCheckDuration("timeout", "10m", 30*time.Second))
+	assert.Equal(t, 30*time.Second, parseHealthCheckDuration("timeout", "foo", 30*time.Second))
+	assert.Equal(t, 30*time.Second, parseHealthCheckDuration("timeout", "-5s", 30*time.Second))
+}
+
+func TestCheckHealthMultipleFailovers(t *testing.T) {
+	setup := func(maxFailovers int, loseQuorum bool) *Cluster {
+		// mons d and e are in the mon map but out of quorum
+		status := &client.MonStatusResponse{Quorum: []int{0, 1, 2}}
+		status.MonMap.Mons = []client.MonMapEntry{
+			{Name: "a", Rank: 0, Address: "1.2.3.1"},
+			{Name: "b", Rank: 1, Address: "1.2.3.2"},
+			{Name: "c", Rank: 2, Address: "1.2.3.3"},
+			{Name: "d", Rank: 3, Address: "1.2.3.4"},
+			{Name: "e", Rank: 4, Address: "1.2.3.5"},
+		}
+		executor := newMonStatusExecutor(status, func(name string) {
+			// quorum is lost after the first failover
+			if loseQuorum {
+				status.Quorum = []int{0, 1}
+			}
+		})
+		configDir, _ := ioutil.TempDir("", "")
+		context := &clusterd.Context{
+			Clientset: test.New(3),
+			ConfigDir: configDir,
+			Executor:  executor,
+		}
+		monSpec := cephv1.MonSpec{Count: 5, AllowMultiplePerNode: true, HealthCheck: cephv1.MonHealthCheckSpec{MaxConcurrentFailover: maxFailovers}}
+		c := New(context, "ns", "", "myversion", cephv1.CephVersionSpec{}, monSpec,
+			rookalpha.Placement{}, false, v1.ResourceRequirements{}, metav1.OwnerReference{})
+		c.clusterInfo = test.CreateConfigDir(5)
+		c.waitForStart = false
+		c.maxMonID = 4
+		c.monTimeoutList["d"] = time.Now().Add(-2 * MonOutTimeout)
+		c.monTimeoutList["e"] = time.Now().Add(-2 * MonOutTimeout)
+		return c
+	}
+
+	// by default only one mon is failed over per health check
+	c := setup(0, false)
+	defer os.RemoveAll(c.context.ConfigDir)
+	assert.Equal(t, DefaultMaxConcurrentFailover, c.maxFailovers)
+	err := c.checkHealth(ctx)
+	assert.Nil(t, err)
+	assert.Nil(t, c.clusterInfo.Monitors["d"])
+	assert.NotNil(t, c.clusterInfo.Monitors["e"])
+	assert.Equal(t, 5, len(c.clusterInfo.Monitors))
+
+	// both dead mons are failed over in one health check while quorum is intact
+	c = setup(2, false)
+	defer os.RemoveAll(c.context.ConfigDir)
+	err = c.checkHealth(ctx)
+	assert.Nil(t, err)
+	assert.Nil(t, c.clusterInfo.Monitors["d"])
+	assert.Nil(t, c.clusterInfo.Monitors["e"])
+	assert.NotNil(t, c.clusterInfo.Monitors["f"])
+	assert.NotNil(t, c.clusterInfo.Monitors["g"])
+	assert.Equal(t, 5, len(c.clusterInfo.Monitors))
+
+	// the second failover is skipped when quorum is lost after the first
+	c = setup(2, true)
+	defer os.RemoveAll(c.context.ConfigDir)
+	err = c.checkHealth(ctx)
+	assert.Nil(t, err)
+	assert.Nil(t, c.clusterInfo.Monitors["d"])
+	assert.NotNil(t, c.clusterInfo.Monitors["e"])
+	assert.NotNil(t, c.clusterInfo.Monitors["f"])
+	assert.Nil(t, c.clusterInfo.Monitors["g"])
+}
+
+func TestCheckHealthFailoverAttempts(t *testing.T) {
+	// mon c is in the mon map but out of quorum, and none of its replacements ever join the mon map
+	status := &client.MonStatusResponse{Quorum: []int{0, 1}}
+	status.MonMap.Mons = []client.MonMapEntry{
+		{Name: "a", Rank: 0, Address: "1.2.3.1"},
+		{Name: "b", Rank: 1, Address: "1.2.3.2"},
+		{Name: "c", Rank: 2, Address: "1.2.3.3"},
+	}
+	executor := newMonStatusExecutor(status, nil)
+	configDir, _ := ioutil.TempDir("", "")
+	defer os.RemoveAll(configDir)
+	rookClientset := rookfake.NewSimpleClientset(&cephv1.CephCluster{ObjectMeta: metav1.ObjectMeta{Name: "ns", Namespace: "ns"}})
+	context := &clusterd.Context{
+		Clientset:     test.New(3),
+		RookClientset: rookClientset,
+		ConfigDir:     configDir,
+		Executor:      executor,
+	}
+	monSpec := cephv1.MonSpec{Count: 3, AllowMultiplePerNode: true, HealthCheck: cephv1.MonHealthCheckSpec{MaxFailoverAttempts: 2}}
+	c := New(context, "ns", "", "myversion", cephv1.CephVersionSpec{}, monSpec,
+		rookalpha.Placement{}, false, v1.ResourceRequirements{}, metav1.OwnerReference{Name: "ns"})
+	recorder := record.NewFakeRecorder(10)
+	c.SetEventRecorder(recorder)
+	c.clusterInfo = test.CreateConfigDir(3)
+	c.waitForStart = false
+	c.maxMonID = 2
+	c.monTimeoutList["c"] = time.Now().Add(-2 * MonOutTimeout)
+
+	// c is replaced by d, then d by e
+	err := c.checkHealth(ctx)
+	assert.Nil(t, err)
+	assert.Nil(t, c.clusterInfo.Monitors["c"])
+	assert.Equal(t, 1, c.failoverAttempts["d"])
+	err = c.checkHealth(ctx)
+	assert.Nil(t, err)
+	assert.Nil(t, c.clusterInfo.Monitors["d"])
+	assert.Equal(t, 2, c.failoverAttempts["e"])
+
+	// e is not replaced and the cluster is marked as degraded
+	err = c.checkHealth(ctx)
+	assert.Nil(t, err)
+	assert.NotNil(t, c.clusterInfo.Monitors["e"])
+	assert.Nil(t, c.clusterInfo.Monitors["f"])
+	cluster, err := rookClientset.CephV1().CephClusters("ns").Get("ns", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, cephv1.ClusterStateDegraded, cluster.Status.State)
+	assert.Contains(t, cluster.Status.Message, "mon e")
+	events := []string{}
+	for len(recorder.Events) > 0 {
+		events = append(events, <-recorder.Events)
+	}
+	assert.Contains(t, events[len(events)-1], MonFailoverStoppedReason)
+
+	// the failover resumes once the mon joins quorum
+	status.MonMap.Mons = append(status.MonMap.Mons, client.MonMapEntry{Name: "e", Rank: 2, Address: "1.2.3.5"})
+	status.Quorum = []int{0, 1, 2}
+	err = c.checkHealth(ctx)
+	assert.Nil(t, err)
+	_, ok := c.failoverAttempts["e"]
+	assert.False(t, ok)
+}
+
+func TestCheckHealthFailoverBackoff(t *testing.T) {
+	defer func(base, max time.Duration) { FailoverBackoff, MaxFailoverBackoff = base, max }(FailoverBackoff, MaxFailoverBackoff)
+	FailoverBackoff = time.Minute
+	MaxFailoverBackoff = time.Hour
+
+	// mon c is in the mon map but out of quorum, and none of its replacements ever join the mon map
+	status := &client.MonStatusResponse{Quorum: []int{0, 1}}
+	status.MonMap.Mons = []client.MonMapEntry{
+		{Name: "a", Rank: 0, Address: "1.2.3.1"},
+		{Name: "b", Rank: 1, Address: "1.2.3.2"},
+		{Name: "c", Rank: 2, Address: "1.2.3.3"},
+	}
+	configDir, _ := ioutil.TempDir("", "")
+	defer os.RemoveAll(configDir)
+	context := &clusterd.Context{Clientset: test.New(3), ConfigDir: configDir, Executor: newMonStatusExecutor(status, nil)}
+	monSpec := cephv1.MonSpec{Count: 3, AllowMultiplePerNode: true, HealthCheck: cephv1.MonHealthCheckSpec{MaxFailoverAttempts: 5}}
+	c := New(context, "ns", "", "myversion", cephv1.CephVersionSpec{}, monSpec,
+		rookalpha.Placement{}, false, v1.ResourceRequirements{}, metav1.OwnerReference{})
+	clock := &fakeClock{now: time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)}
+	c.clock = clock
+	c.clusterInfo = test.CreateConfigDir(3)
+	c.waitForStart = false
+	c.maxMonID = 2
+	c.monTimeoutList["c"] = clock.Now().Add(-2 * MonOutTimeout)
+
+	// c is failed over at once, then each replacement waits longer than the previous one
+	delays := []time.Duration{}
+	for _, name := range []string{"d", "e", "f"} {
+		err := c.checkHealth(ctx)
+		assert.Nil(t, err)
+		backoff, ok := c.failoverBackoff[name]
+		if !assert.True(t, ok, name) {
+			return
+		}
+		delays = append(delays, backoff.until.Sub(clock.Now()))
+
+		// the replacement is not failed over before its backoff expires
+		clock.now = backoff.until.Add(-time.Second)
+		err = c.checkHealth(ctx)
+		assert.Nil(t, err)
+		assert.NotNil(t, c.clusterInfo.Monitors[name])
+		clock.now = backoff.until
+	}
+	assert.Equal(t, []time.Duration{time.Minute, 2 * time.Minute, 4 * time.Minute}, delays)
+
+	// the backoff is reset once the mon stays in quorum for the max backoff
+	status.MonMap.Mons = append(status.MonMap.Mons[:2], client.MonMapEntry{Name: "f", Rank: 2, Address: "1.2.3.6"})
+	status.Quorum = []int{0, 1, 2}
+	err := c.checkHealth(ctx)
+	assert.Nil(t, err)
+	assert.NotNil(t, c.failoverBackoff["f"])
+	clock.now = clock.now.Add(MaxFailoverBackoff)
+	err = c.checkHealth(ctx)
+	assert.Nil(t, err)
+	_, ok := c.failoverBackoff["f"]
+	assert.False(t, ok)
+}
+
+func TestFailoverDelay(t *testing.T) {
+	assert.Equal(t, time.Duration(0), failoverDelay(0, time.Minute, time.Hour))
+	assert.Equal(t, time.Duration(0), failoverDelay(3, 0, time.Hour))
+	assert.Equal(t, time.Minute, failoverDelay(1, time.Minute, time.Hour))
+	assert.Equal(t, 8*time.Minute, failoverDelay(4, time.Minute, time.Hour))
+	assert.Equal(t, time.Hour, failoverDelay(7, time.Minute, time.Hour))
+	assert.Equal(t, time.Hour, failoverDelay(1000, time.Minute, time.Hour))
+	assert.Equal(t, time.Minute, failoverDelay(3, time.Minute, time.Second))
+}
+
+// fakeMonStatus is a MonStatusGetter that returns a canned mon status
+type fakeMonStatus struct {
+	status client.MonStatusResponse
+	err    error
+	calls  int
+}
+
+func (f *fakeMonStatus) GetMonStatus(context *clusterd.Context, clusterName string, debug bool) (client.MonStatusResponse, error) {
+	f.calls++
+	return f.status, f.err
+}
+
+func TestCheckHealthFakeMonStatus(t *testing.T) {
+	deleteDeployment := deleteMonDeployment
+	defer func() {
+		deleteMonDeployment = deleteDeployment
+	}()
+	deleted := []string{}
+	deleteMonDeployment = func(clientset kubernetes.Interface, namespace, name string, options *metav1.DeleteOptions) error {
+		deleted = append(deleted, name)
+		return nil
+	}
+
+	// mon c is in the mon map but out of quorum
+	fake := &fakeMonStatus{status: client.MonStatusResponse{Quorum: []int{0, 1}}}
+	fake.status.MonMap.Mons = []client.MonMapEntry{
+		{Name: "a", Rank: 0, Address: "1.2.3.1"},
+		{Name: "b", Rank: 1, Address: "1.2.3.2"},
+		{Name: "c", Rank: 2, Address: "1.2.3.3"},
+	}
+	// only the removal of a mon from the mon map goes to the executor, the mon status comes from the fake
+	removed := []string{}
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(debug bool, actionName string, command string, outFileArg string, args ...string) (string, error) {
+			if len(args) > 2 && args[0] == "mon" && args[1] == "remove" {
+				removeFromMonStatus(&fake.status, args[2])
+				removed = append(removed, args[2])
+				return "", nil
+			}
+			assert.NotEqual(t, "mon_status", args[0])
+			return "", fmt.Errorf("unexpected command %v", args)
+		},
+	}
+	configDir, _ := ioutil.TempDir("", "")
+	defer os.RemoveAll(configDir)
+	context := &clusterd.Context{Clientset: test.New(3), ConfigDir: configDir, Executor: executor}
+	c := newCluster(context, "ns", false, v1.ResourceRequirements{})
+	c.monStatus = fake
+	clock := &fakeClock{now: time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)}
+	c.clock = clock
+	c.clusterInfo = test.CreateConfigDir(3)
+	c.maxMonID = 2
+
+	// c is still within the mon out timeout
+	err := c.checkHealth(ctx)
+	assert.Nil(t, err)
+	assert.NotNil(t, c.clusterInfo.Monitors["c"])
+	assert.Equal(t, clock.Now(), c.monTimeoutList["c"])
+	assert.Equal(t, 0, len(removed))
+
+	// c is replaced by d once the timeout is exceeded
+	clock.now = clock.now.Add(MonOutTimeout + time.Second)
+	err = c.checkHealth(ctx)
+	assert.Nil(t, err)
+	assert.Nil(t, c.clusterInfo.Monitors["c"])
+	assert.NotNil(t, c.clusterInfo.Monitors["d"])
+	assert.Equal(t, []string{"c"}, removed)
+	assert.Equal(t, []string{"rook-ceph-mon-c"}, deleted)
+	assert.True(t, fake.calls > 0)
+}
+
+func TestCheckHealthEmptyMonMap(t *testing.T) {
+	// the status is returned without any mon in the mon map
+	fake := &fakeMonStatus{}
+	clientset := test.New(3)
+	configDir, _ := ioutil.TempDir("", "")
+	defer os.RemoveAll(configDir)
+	rookClientset := rookfake.NewSimpleClientset(&cephv1.CephCluster{ObjectMeta: metav1.ObjectMeta{Name: "ns", Namespace: "ns"}})
+	context := &clusterd.Context{Clientset: clientset, RookClientset: rookClientset, ConfigDir: configDir, Executor: &exectest.MockExecutor{}}
+	c := newCluster(context, "ns", false, v1.ResourceRequirements{})
+	c.ownerRef = metav1.OwnerReference{Name: "ns"}
+	c.monStatus = fake
+	c.clusterInfo = test.CreateConfigDir(3)
+	c.maxMonID = 2
+
+	// no mons are started, the mons are treated as unreachable instead
+	err := c.checkHealth(ctx)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "the mon map is empty")
+	assert.Equal(t, 1, fake.calls)
+	assert.True(t, c.quorumUnreachable)
+	assert.Equal(t, QuorumUnreachableInterval, c.getHealthCheckInterval())
+	assert.Equal(t, 3, len(c.clusterInfo.Monitors))
+	assert.Equal(t, 2, c.maxMonID)
+	deployments, err := clientset.Extensions().Deployments("ns").List(metav1.ListOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(deployments.Items))
+	services, err := clientset.CoreV1().Services("ns").List(metav1.ListOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(services.Items))
+}
+
+func TestSyncMonsWithMonMap(t *testing.T) {
+	// mon d was added and mon c was removed while the operator was down
+	fake := &fakeMonStatus{status: client.MonStatusResponse{Quorum: []int{0, 1, 2}}}
+	fake.status.MonMap.Mons = []client.MonMapEntry{
+		{Name: "a", Rank: 0, Address: "1.2.3.1:6790/0"},
+		{Name: "b", Rank: 1, Address: "1.2.3.2:6790/0"},
+		{Name: "d", Rank: 2, Address: "1.2.3.4:6790/0"},
+	}
+	configDir, _ := ioutil.TempDir("", "")
+	defer os.RemoveAll(configDir)
+	context := &clusterd.Context{Clientset: test.New(3), ConfigDir: configDir, Executor: &exectest.MockExecutor{}}
+	c := newCluster(context, "ns", false, v1.ResourceRequirements{})
+	c.monStatus = fake
+	c.clusterInfo = test.CreateConfigDir(3)
+	c.maxMonID = 2
+
+	err := c.syncMonsWithMonMap()
+	assert.Nil(t, err)
+	assert.Equal(t, 4, len(c.clusterInfo.Monitors))
+	assert.Equal(t, "1.2.3.4:6790", c.clusterInfo.Monitors["d"].Endpoint)
+	assert.NotNil(t, c.clusterInfo.Monitors["c"])
+	assert.Equal(t, 3, c.maxMonID)
+	cm, err := context.Clientset.CoreV1().ConfigMaps("ns").Get(EndpointConfigMapName, metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Contains(t, cm.Data[EndpointDataKey], "d=1.2.3.4:6790")
+
+	// nothing is changed for an empty mon map
+	fake.status.MonMap.Mons = []client.MonMapEntry{}
+	err = c.syncMonsWithMonMap()
+	assert.NotNil(t, err)
+	assert.Equal(t, 4, len(c.clusterInfo.Monitors))
+}
+
+func TestSyncMonsWithMonMapDryRun(t *testing.T) {
+	writeConfig := writeMonConnectionConfig
+	defer func() {
+		writeMonConnectionConfig = writeConfig
+	}()
+	writes := 0
+	writeMonConnectionConfig = func(context *clusterd.Context, clusterInfo *cephconfig.ClusterInfo) error {
+		writes++
+		return nil
+	}
+
+	// mon d was added while the operator was down
+	fake := &fakeMonStatus{status: client.MonStatusResponse{Quorum: []int{0, 1, 2, 3}}}
+	fake.status.MonMap.Mons = []client.MonMapEntry{
+		{Name: "a", Rank: 0, Address: "1.2.3.1:6790/0"},
+		{Name: "b", Rank: 1, Address: "1.2.3.2:6790/0"},
+		{Name: "c", Rank: 2, Address: "1.2.3.3:6790/0"},
+		{Name: "d", Rank: 3, Address: "1.2.3.4:6790/0"},
+	}
+	configDir, _ := ioutil.TempDir("", "")
+	defer os.RemoveAll(configDir)
+	context := &clusterd.Context{Clientset: test.New(3), ConfigDir: configDir, Executor: &exectest.MockExecutor{}}
+	c := newCluster(context, "sync-dry-run-ns", false, v1.ResourceRequirements{})
+	c.monStatus = fake
+	c.clusterInfo = test.CreateConfigDir(3)
+	c.maxMonID = 2
+	c.UpdateHealthCheck(cephv1.MonHealthCheckSpec{DryRun: true})
+	err := c.saveMonConfig()
+	assert.Nil(t, err)
+	before, err := context.Clientset.CoreV1().ConfigMaps("sync-dry-run-ns").Get(EndpointConfigMapName, metav1.GetOptions{})
+	assert.Nil(t, err)
+
+	// the mon is only counted, and the mon config and the connection config are not written
+	err = c.syncMonsWithMonMap()
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(c.clusterInfo.Monitors))
+	assert.Equal(t, 2, c.maxMonID)
+	assert.Equal(t, 0, writes)
+	assert.Equal(t, float64(1), dryRunActionCount(t, "sync-dry-run-ns", "", monActionAdd))
+	after, err := context.Clientset.CoreV1().ConfigMaps("sync-dry-run-ns").Get(EndpointConfigMapName, metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, before.Data, after.Data)
+}
+
+func TestCheckHealthLastInQuorum(t *testing.T) {
+	// mon c is in the mon map but out of quorum
+	status := &client.MonStatusResponse{Quorum: []int{0, 1}}
+	status.MonMap.Mons = []client.MonMapEntry{
+		{Name: "a", Rank: 0, Address: "1.2.3.1"},
+		{Name: "b", Rank: 1, Address: "1.2.3.2"},
+		{Name: "c", Rank: 2, Address: "1.2.3.3"},
+	}
+	configDir, _ := ioutil.TempDir("", "")
+	defer os.RemoveAll(configDir)
+	context := &clusterd.Context{Clientset: test.New(3), ConfigDir: configDir, Executor: newMonStatusExecutor(status, nil)}
+	c := newCluster(context, "ns", false, v1.ResourceRequirements{})
+	clock := &fakeClock{now: time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)}
+	c.clock = clock
+	c.clusterInfo = test.CreateConfigDir(3)
+	defer removeHealthState("ns")
+
+	first := clock.Now()
+	err := c.checkHealth(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]time.Time{"a": first, "b": first}, c.lastInQuorum)
+
+	// the time advances for the mons in quorum, and c keeps the last time it was seen in quorum
+	clock.now = clock.now.Add(time.Minute)
+	status.Quorum = []int{0, 1, 2}
+	err = c.checkHealth(ctx)
+	assert.Nil(t, err)
+	second := clock.Now()
+	assert.Equal(t, map[string]time.Time{"a": second, "b": second, "c": second}, c.lastInQuorum)
+
+	clock.now = clock.now.Add(time.Minute)
+	status.Quorum = []int{0, 1}
+	err = c.checkHealth(ctx)
+	assert.Nil(t, err)
+	third := clock.Now()
+	assert.Equal(t, map[string]time.Time{"a": third, "b": third, "c": second}, c.lastInQuorum)
+
+	// a mon that left the mon map is forgotten
+	removeFromMonStatus(status, "c")
+	clock.now = clock.now.Add(time.Minute)
+	err = c.checkHealth(ctx)
+	assert.Nil(t, err)
+	_, ok := c.lastInQuorum["c"]
+	assert.False(t, ok)
+
+	// the times are served with the health state
+	healthStatesLock.RLock()
+	state := healthStates["ns"]
+	healthStatesLock.RUnlock()
+	assert.Equal(t, c.lastInQuorum, state.LastInQuorum)
+}
+
+func TestCheckHealthQuorumUnreachable(t *testing.T) {
+	// mon c is in the mon map but out of quorum
+	status := &client.MonStatusResponse{Quorum: []int{0, 1}}
+	status.MonMap.Mons = []client.MonMapEntry{
+		{Name: "a", Rank: 0, Address: "1.2.3.1"},
+		{Name: "b", Rank: 1, Address: "1.2.3.2"},
+		{Name: "c", Rank: 2, Address: "1.2.3.3"},
+	}
+	unreachable := true
+	statusExecutor := newMonStatusExecutor(status, nil)
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(debug bool, actionName string, command string, outFileArg string, args ...string) (string, error) {
+			if unreachable {
+				return "", fmt.Errorf("mock timed out (500 sec)")
+			}
+			return statusExecutor.MockExecuteCommandWithOutputFile(debug, actionName, command, outFileArg, args...)
+		},
+	}
+	configDir, _ := ioutil.TempDir("", "")
+	defer os.RemoveAll(configDir)
+	rookClientset := rookfake.NewSimpleClientset(&cephv1.CephCluster{ObjectMeta: metav1.ObjectMeta{Name: "ns", Namespace: "ns"}})
+	context := &clusterd.Context{
+		Clientset:     test.New(3),
+		RookClientset: rookClientset,
+		ConfigDir:     configDir,
+		Executor:      executor,
+	}
+	c := New(context, "ns", "", "myversion", cephv1.CephVersionSpec{}, cephv1.MonSpec{Count: 3, AllowMultiplePerNode: true},
+		rookalpha.Placement{}, false, v1.ResourceRequirements{}, metav1.OwnerReference{Name: "ns"})
+	recorder := record.NewFakeRecorder(10)
+	c.SetEventRecorder(recorder)
+	c.clusterInfo = test.CreateConfigDir(3)
+	c.waitForStart = false
+	c.maxMonID = 2
+	c.monTimeoutList["c"] = time.Now().Add(-2 * MonOutTimeout)
+	clusterState := func() cephv1.ClusterState {
+		cluster, err := rookClientset.CephV1().CephClusters("ns").Get("ns", metav1.GetOptions{})
+		assert.Nil(t, err)
+		return cluster.Status.State
+	}
+
+	// no mon is failed over while the quorum is unreachable
+	err := c.checkHealth(ctx)
+	assert.NotNil(t, err)
+	assert.Equal(t, cephv1.ClusterStateQuorumUnreachable, clusterState())
+	assert.NotNil(t, c.clusterInfo.Monitors["c"])
+	assert.Nil(t, c.clusterInfo.Monitors["d"])
+	assert.Equal(t, 1, len(recorder.Events))
+	assert.Contains(t, <-recorder.Events, MonQuorumUnreachableReason)
+	assert.Equal(t, QuorumUnreachableInterval, c.getHealthCheckInterval())
+	assert.Equal(t, MonQuorumUnreachableReason, c.monsCondition.Reason)
+
+	// the unreachable quorum is only reported once
+	err = c.checkHealth(ctx)
+	assert.NotNil(t, err)
+	assert.Equal(t, 0, len(recorder.Events))
+
+	// the status is reset and the failover goes ahead once the mons can be reached
+	unreachable = false
+	err = c.checkHealth(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, cephv1.ClusterStateCreated, clusterState())
+	assert.Equal(t, HealthCheckInterval, c.getHealthCheckInterval())
+	assert.Equal(t, MonsOutOfQuorumReason, c.monsCondition.Reason)
+	assert.Nil(t, c.clusterInfo.Monitors["c"])
+	assert.NotNil(t, c.clusterInfo.Monitors["d"])
+}
+
+func TestCheckHealthDeferOnHealthErr(t *testing.T) {
+	// mon e is in the mon map but out of quorum
+	resp := client.MonStatusResponse{Quorum: []int{0, 1, 2, 3}}
+	resp.MonMap.Mons = []client.MonMapEntry{
+		{Name: "a", Rank: 0, Address: "1.2.3.1"},
+		{Name: "b", Rank: 1, Address: "1.2.3.2"},
+		{Name: "c", Rank: 2, Address: "1.2.3.3"},
+		{Name: "d", Rank: 3, Address: "1.2.3.4"},
+		{Name: "e", Rank: 4, Address: "1.2.3.5"},
+	}
+	health := client.HealthStatus{
+		Status: client.CephHealthErr,
+		Checks: map[string]client.CheckMessage{
+			"OSD_FULL": {Severity: client.CephHealthErr},
+			"MON_DOWN": {Severity: client.CephHealthWarn},
+		},
+	}
+	statusExecutor := newMonStatusExecutor(&resp, nil)
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(debug bool, actionName string, command string, outFileArg string, args ...string) (string, error) {
+			if args[0] == "status" {
+				serialized, _ := json.Marshal(client.CephStatus{Health: health})
+				return string(serialized), nil
+			}
+			return statusExecutor.MockExecuteCommandWithOutputFile(debug, actionName, command, outFileArg, args...)
+		},
+	}
+	configDir, _ := ioutil.TempDir("", "")
+	defer os.RemoveAll(configDir)
+	context := &clusterd.Context{
+		Clientset: test.New(5),
+		ConfigDir: configDir,
+		Executor:  executor,
+	}
+	c := New(context, "ns", "", "myversion", cephv1.CephVersionSpec{},
+		cephv1.MonSpec{Count: 5, AllowMultiplePerNode: true, HealthCheck: cephv1.MonHealthCheckSpec{DeferFailoverOnHealthErr: true}},
+		rookalpha.Placement{}, false, v1.ResourceRequirements{}, metav1.OwnerReference{})
+	c.clusterInfo = test.CreateConfigDir(5)
+	c.waitForStart = false
+	c.maxMonID = 4
+	c.monTimeoutList["e"] = time.Now().Add(-2 * MonOutTimeout)
+
+	// the failover is deferred while the osds are full
+	err := c.checkHealth(ctx)
+	assert.Nil(t, err)
+	assert.NotNil(t, c.clusterInfo.Monitors["e"])
+	assert.Nil(t, c.clusterInfo.Monitors["f"])
+
+	// an error about the mons does not defer the failover
+	health.Checks = map[string]client.CheckMessage{"MON_DISK_CRIT": {Severity: client.CephHealthErr}}
+	err = c.checkHealth(ctx)
+	assert.Nil(t, err)
+	assert.Nil(t, c.clusterInfo.Monitors["e"])
+	assert.NotNil(t, c.clusterInfo.Monitors["f"])
+}
+
+func TestFilterNonMonHealthErrors(t *testing.T) {
+	health := client.HealthStatus{
+		Status: client.CephHealthErr,
+		Checks: map[string]client.CheckMessage{
+			"POOL_FULL":     {Severity: client.CephHealthErr},
+			"OSD_FULL":      {Severity: client.CephHealthErr},
+			"MON_DISK_CRIT": {Severity: client.CephHealthErr},
+			"OSD_DOWN":      {Severity: client.CephHealthWarn},
+		},
+	}
+	assert.Equal(t, []string{"OSD_FULL", "POOL_FULL"}, filterNonMonHealthErrors(health))
+
+	// only the mons are in error
+	delete(health.Checks, "POOL_FULL")
+	delete(health.Checks, "OSD_FULL")
+	assert.Equal(t, 0, len(filterNonMonHealthErrors(health)))
+
+	// the cluster is not in error
+	health.Status = client.CephHealthWarn
+	health.Checks["OSD_FULL"] = client.CheckMessage{Severity: client.CephHealthErr}
+	assert.Equal(t, 0, len(filterNonMonHealthErrors(health)))
+}
+
+func TestQuorumAtRisk(t *testing.T) {
+	status := client.MonStatusResponse{}
+	status.MonMap.Mons = make([]client.MonMapEntry, 3)
+
+	// losing another of three mons breaks quorum
+	status.Quorum = []int{0, 1}
+	assert.True(t, quorumAtRisk(status))
+	status.Quorum = []int{0, 1, 2}
+	assert.False(t, quorumAtRisk(status))
+
+	// five mons can lose another mon with four in quorum, but not with three
+	status.MonMap.Mons = make([]client.MonMapEntry, 5)
+	status.Quorum = []int{0, 1, 2, 3}
+	assert.False(t, quorumAtRisk(status))
+	status.Quorum = []int{0, 1, 2}
+	assert.True(t, quorumAtRisk(status))
+}
+
+func TestProposeRebalanceMove(t *testing.T) {
+	clientset := test.New(4)
+	setPreferred := func(name string, preferred bool) {
+		node, err := clientset.CoreV1().Nodes().Get(name, metav1.GetOptions{})
+		assert.Nil(t, err)
+		node.Labels = map[string]string{}
+		if preferred {
+			node.Labels["mon"] = "preferred"
+		}
+		clientset.CoreV1().Nodes().Update(node)
+	}
+	setPreferred("node3", true)
+	c := newCluster(&clusterd.Context{Clientset: clientset}, "ns", false, v1.ResourceRequirements{})
+	c.placement = rookalpha.Placement{NodeAffinity: &v1.NodeAffinity{
+		PreferredDuringSchedulingIgnoredDuringExecution: []v1.PreferredSchedulingTerm{
+			{Weight: 1, Preference: v1.NodeSelectorTerm{MatchExpressions: []v1.NodeSelectorRequirement{
+				{Key: "mon", Operator: v1.NodeSelectorOpIn, Values: []string{"preferred"}},
+			}}},
+		},
+	}}
+	c.clusterInfo = test.CreateConfigDir(3)
+	c.mapping.Node["a"] = &NodeInfo{Name: "node0"}
+	c.mapping.Node["b"] = &NodeInfo{Name: "node1"}
+	c.mapping.Node["c"] = &NodeInfo{Name: "node2"}
+
+	// a single mon is moved to the preferred node
+	mon, node, ok, err := c.proposeRebalanceMove()
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "a", mon)
+	assert.Equal(t, "node3", node)
+
+	// no move when the mon would not land on a preferred node
+	setPreferred("node3", false)
+	_, _, ok, err = c.proposeRebalanceMove()
+	assert.Nil(t, err)
+	assert.False(t, ok)
+
+	// no move when all the mons are on preferred nodes
+	for _, name := range []string{"node0", "node1", "node2", "node3"} {
+		setPreferred(name, true)
+	}
+	_, _, ok, err = c.proposeRebalanceMove()
+	assert.Nil(t, err)
+	assert.False(t, ok)
+
+	// no move without preferred nodes in the placement
+	c.placement = rookalpha.Placement{}
+	_, _, ok, err = c.proposeRebalanceMove()
+	assert.Nil(t, err)
+	assert.False(t, ok)
+}
+
+func TestMonsHealthyCondition(t *testing.T) {
+	report := MonHealthReport{
+		DesiredCount: 3,
+		Mons:         []MonHealth{{Name: "a", InQuorum: true}, {Name: "b", InQuorum: true}, {Name: "c", InQuorum: true}},
+		MissingMons:  []string{},
+		AllInQuorum:  true,
+	}
+
+	// healthy when the desired count is running and all are in quorum
+	condition := monsHealthyCondition(report, 3)
+	assert.Equal(t, v1.ConditionTrue, condition.Status)
+	assert.Equal(t, MonsInQuorumReason, condition.Reason)
+
+	// too few mons in the mon map
+	condition = monsHealthyCondition(report, 2)
+	assert.Equal(t, v1.ConditionFalse, condition.Status)
+	assert.Equal(t, MonCountMismatchReason, condition.Reason)
+
+	// a mon is missing from the mon map
+	report.MissingMons = []string{"d"}
+	condition = monsHealthyCondition(report, 3)
+	assert.Equal(t, v1.ConditionFalse, condition.Status)
+	assert.Equal(t, MonsMissingReason, condition.Reason)
+	assert.Contains(t, condition.Message, "d")
+
+	// a mon out of quorum takes precedence
+	report.Mons[1].InQuorum = false
+	report.AllInQuorum = false
+	condition = monsHealthyCondition(report, 3)
+	assert.Equal(t, v1.ConditionFalse, condition.Status)
+	assert.Equal(t, MonsOutOfQuorumReason, condition.Reason)
+	assert.Contains(t, condition.Message, "b")
+}
+
+func TestSetMonsHealthy(t *testing.T) {
+	rookClientset := rookfake.NewSimpleClientset(&cephv1.CephCluster{ObjectMeta: metav1.ObjectMeta{Name: "ns", Namespace: "ns"}})
+	context := &clusterd.Context{Clientset: test.New(3), RookClientset: rookClientset}
+	c := New(context, "ns", "", "myversion", cephv1.CephVersionSpec{}, cephv1.MonSpec{Count: 3, AllowMultiplePerNode: true},
+		rookalpha.Placement{}, false, v1.ResourceRequirements{}, metav1.OwnerReference{Name: "ns"})
+	clock := &fakeClock{now: time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)}
+	c.clock = clock
+	getCondition := func() cephv1.ClusterCondition {
+		cluster, err := rookClientset.CephV1().CephClusters("ns").Get("ns", metav1.GetOptions{})
+		assert.Nil(t, err)
+		assert.Equal(t, 1, len(cluster.Status.Conditions))
+		return cluster.Status.Conditions[0]
+	}
+	updates := func() int {
+		count := 0
+		for _, action := range rookClientset.Actions() {
+			if action.GetVerb() == "update" {
+				count++
+			}
+		}
+		return count
+	}
+	healthy := cephv1.ClusterCondition{Status: v1.ConditionTrue, Reason: MonsInQuorumReason, Message: "all 3 mons are in quorum"}
+
+	// the condition is added
+	c.setMonsHealthy(healthy)
+	condition := getCondition()
+	assert.Equal(t, cephv1.ClusterConditionMonsHealthy, condition.Type)
+	assert.Equal(t, v1.ConditionTrue, condition.Status)
+	assert.Equal(t, clock.now, condition.LastTransitionTime.Time)
+	assert.Equal(t, 1, updates())
+
+	// the same condition does not update the crd again
+	clock.now = clock.now.Add(time.Minute)
+	c.setMonsHealthy(healthy)
+	assert.Equal(t, 1, updates())
+
+	// the condition is not written again after a restart of the operator when the crd is up to date
+	c.monsCondition = cephv1.ClusterCondition{}
+	c.setMonsHealthy(healthy)
+	assert.Equal(t, 1, updates())
+
+	// a degraded mon flips the condition
+	transition := clock.now
+	c.setMonsHealthy(cephv1.ClusterCondition{Status: v1.ConditionFalse, Reason: MonsOutOfQuorumReason, Message: "mons c are out of quorum"})
+	condition = getCondition()
+	assert.Equal(t, v1.ConditionFalse, condition.Status)
+	assert.Equal(t, MonsOutOfQuorumReason, condition.Reason)
+	assert.Equal(t, transition, condition.LastTransitionTime.Time)
+	assert.Equal(t, 2, updates())
+
+	// another degraded reason keeps the transition time
+	clock.now = clock.now.Add(time.Minute)
+	c.setMonsHealthy(cephv1.ClusterCondition{Status: v1.ConditionFalse, Reason: MonsMissingReason, Message: "mons d are missing from the mon map"})
+	condition = getCondition()
+	assert.Equal(t, MonsMissingReason, condition.Reason)
+	assert.Equal(t, transition, condition.LastTransitionTime.Time)
+	assert.Equal(t, 3, updates())
+
+	// the state and message of the cluster keep the condition
+	err := c.updateClusterStatus(cephv1.ClusterStateCreated, "")
+	assert.Nil(t, err)
+	assert.Equal(t, MonsMissingReason, getCondition().Reason)
+
+	// healthy again
+	c.setMonsHealthy(healthy)
+	condition = getCondition()
+	assert.Equal(t, v1.ConditionTrue, condition.Status)
+	assert.Equal(t, clock.now, condition.LastTransitionTime.Time)
+}
+
+func TestValidateMonCount(t *testing.T) {
+	rookClientset := rookfake.NewSimpleClientset(&cephv1.CephCluster{ObjectMeta: metav1.ObjectMeta{Name: "ns", Namespace: "ns"}})
+	context := &clusterd.Context{Clientset: test.New(3), RookClientset: rookClientset}
+	c := New(context, "ns", "", "myversion", cephv1.CephVersionSpec{}, cephv1.MonSpec{Count: 3, AllowMultiplePerNode: true},
+		rookalpha.Placement{}, false, v1.ResourceRequirements{}, metav1.OwnerReference{Name: "ns"})
+	recorder := record.NewFakeRecorder(10)
+	c.SetEventRecorder(recorder)
+	clusterStatus := func() cephv1.ClusterStatus {
+		cluster, err := rookClientset.CephV1().CephClusters("ns").Get("ns", metav1.GetOptions{})
+		assert.Nil(t, err)
+		return cluster.Status
+	}
+
+	// a normal count is not changed
+	count, err := c.validateMonCount(3, 9)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, count)
+	assert.Equal(t, 0, len(recorder.Events))
+	assert.Equal(t, cephv1.ClusterState(""), clusterStatus().State)
+
+	// a count of 0 is refused
+	count, err = c.validateMonCount(0, 9)
+	assert.NotNil(t, err)
+	assert.Equal(t, 0, count)
+	assert.Equal(t, cephv1.ClusterStateError, clusterStatus().State)
+	assert.Contains(t, clusterStatus().Message, "mon count 0 is not valid")
+	assert.Contains(t, <-recorder.Events, MonCountInvalidReason)
+
+	// the health check leaves the mons alone with a count of 0
+	c.Count = 0
+	err = c.checkHealth(ctx)
+	assert.NotNil(t, err)
+	// the same invalid count is only reported once
+	assert.Equal(t, 0, len(recorder.Events))
+
+	// a count above the max is lowered to the max
+	count, err = c.validateMonCount(11, 9)
+	assert.Nil(t, err)
+	assert.Equal(t, 9, count)
+	assert.Contains(t, clusterStatus().Message, "mon count 11 is above the max of 9")
+	assert.Contains(t, <-recorder.Events, MonCountInvalidReason)
+
+	// the max is configurable
+	count, err = c.validateMonCount(11, 11)
+	assert.Nil(t, err)
+	assert.Equal(t, 11, count)
+	assert.Equal(t, 0, len(recorder.Events))
+}
+
+func TestCheckHealthSuspendFailover(t *testing.T) {
+	newSuspendedCluster := func(suspendUntil string) *Cluster {
+		// mon c is in the mon map but out of quorum
+		status := &client.MonStatusResponse{Quorum: []int{0, 1}}
+		status.MonMap.Mons = []client.MonMapEntry{
+			{Name: "a", Rank: 0, Address: "1.2.3.1"},
+			{Name: "b", Rank: 1, Address: "1.2.3.2"},
+			{Name: "c", Rank: 2, Address: "1.2.3.3"},
+		}
+		executor := newMonStatusExecutor(status, nil)
+		configDir, _ := ioutil.TempDir("", "")
+		context := &clusterd.Context{
+			Clientset: test.New(3),
+			ConfigDir: configDir,
+			Executor:  executor,
+		}
+		monSpec := cephv1.MonSpec{Count: 3, AllowMultiplePerNode: true, HealthCheck: cephv1.MonHealthCheckSpec{SuspendFailoverUntil: suspendUntil}}
+		c := New(context, "ns", "", "myversion", cephv1.CephVersionSpec{}, monSpec,
+			rookalpha.Placement{}, false, v1.ResourceRequirements{}, metav1.OwnerReference{})
+		c.clusterInfo = test.CreateConfigDir(3)
+		c.waitForStart = false
+		c.maxMonID = 2
+		c.monTimeoutList["c"] = time.Now().Add(-2 * MonOutTimeout)
+		return c
+	}
+
+	// mon c is not failed over while failover is suspended
+	c := newSuspendedCluster(time.Now().Add(time.Hour).Format(time.RFC3339))
+	defer os.RemoveAll(c.context.ConfigDir)
+	err := c.checkHealth(ctx)
+	assert.Nil(t, err)
+	assert.NotNil(t, c.clusterInfo.Monitors["c"])
+	assert.Nil(t, c.clusterInfo.Monitors["d"])
+	// the out timeout is still tracked
+	_, ok := c.monTimeoutList["c"]
+	assert.True(t, ok)
+
+	// mon c is failed over once the suspension has expired
+	c = newSuspendedCluster(time.Now().Add(-time.Minute).Format(time.RFC3339))
+	defer os.RemoveAll(c.context.ConfigDir)
+	err = c.checkHealth(ctx)
+	assert.Nil(t, err)
+	assert.Nil(t, c.clusterInfo.Monitors["c"])
+	assert.NotNil(t, c.clusterInfo.Monitors["d"])
+
+	// mon c is failed over when the suspension is cleared
+	c = newSuspendedCluster(time.Now().Add(time.Hour).Format(time.RFC3339))
+	defer os.RemoveAll(c.context.ConfigDir)
+	c.UpdateHealthCheck(cephv1.MonHealthCheckSpec{})
+	err = c.checkHealth(ctx)
+	assert.Nil(t, err)
+	assert.Nil(t, c.clusterInfo.Monitors["c"])
+	assert.NotNil(t, c.clusterInfo.Monitors["d"])
+}
+
+func TestCheckHealthSuspendRemoval(t *testing.T) {
+	newSuspendedCluster := func(suspendUntil string, monMap []string, expected int) (*Cluster, *[]string) {
+		status := &client.MonStatusResponse{}
+		for i, name := range monMap {
+			status.Quorum = append(status.Quorum, i)
+			status.MonMap.Mons = append(status.MonMap.Mons, client.MonMapEntry{Name: name, Rank: i, Address: fmt.Sprintf("1.2.3.%d", i+1)})
+		}
+		removed := []string{}
+		configDir, _ := ioutil.TempDir("", "")
+		context := &clusterd.Context{
+			Clientset: test.New(3),
+			ConfigDir: configDir,
+			Executor:  newMonStatusExecutor(status, func(name string) { removed = append(removed, name) }),
+		}
+		monSpec := cephv1.MonSpec{Count: 3, AllowMultiplePerNode: true, HealthCheck: cephv1.MonHealthCheckSpec{SuspendFailoverUntil: suspendUntil}}
+		c := New(context, "ns", "", "myversion", cephv1.CephVersionSpec{}, monSpec,
+			rookalpha.Placement{}, false, v1.ResourceRequirements{}, metav1.OwnerReference{})
+		c.clusterInfo = test.CreateConfigDir(expected)
+		c.waitForStart = false
+		c.maxMonID = expected - 1
+		return c, &removed
+	}
+	suspended := time.Now().Add(time.Hour).Format(time.RFC3339)
+
+	// the extra mon d is not removed while the mons are suspended
+	c, removed := newSuspendedCluster(suspended, []string{"a", "b", "c", "d"}, 4)
+	defer os.RemoveAll(c.context.ConfigDir)
+	err := c.checkHealth(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(*removed))
+	assert.NotNil(t, c.clusterInfo.Monitors["d"])
+
+	// the extra mon is removed once the suspension is cleared
+	c.UpdateHealthCheck(cephv1.MonHealthCheckSpec{})
+	err = c.checkHealth(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"d"}, *removed)
+
+	// mon e is in quorum but not in the source of truth, and is not removed while the mons are suspended
+	c, removed = newSuspendedCluster(suspended, []string{"a", "b", "c", "e"}, 3)
+	defer os.RemoveAll(c.context.ConfigDir)
+	err = c.checkHealth(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(*removed))
+
+	// mon e is removed once the suspension is cleared
+	c.UpdateHealthCheck(cephv1.MonHealthCheckSpec{})
+	err = c.checkHealth(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"e"}, *removed)
+}
+
+func TestParseSuspendFailoverUntil(t *testing.T) {
+	now := time.Date(2018, 11, 5, 12, 0, 0, 0, time.UTC)
+	assert.True(t, parseSuspendFailoverUntil("", now).IsZero())
+	assert.True(t, parseSuspendFailoverUntil("tomorrow", now).IsZero())
+
+	until := parseSuspendFailoverUntil("2018-11-05T18:00:00Z", now)
+	assert.True(t, until.Equal(now.Add(6*time.Hour)))
+
+	// a suspension longer than the max is capped
+	until = parseSuspendFailoverUntil("2018-12-05T12:00:00Z", now)
+	assert.True(t, until.Equal(now.Add(MaxFailoverSuspension)))
+}
+
+func TestCanSafelyRemoveMon(t *testing.T) {
+	tests := []struct {
+		current   int
+		desired   int
+		allowEven bool
+		expected  bool
+	}{
+		// never remove the last mons
+		{3, 0, false, false},
+		{1, 0, false, false},
+		{3, 0, true, false},
+		// reduce to a single mon, but not from two mons
+		{3, 1, false, true},
+		{2, 1, false, false},
+		{1, 1, false, false},
+		{2, 1, true, false},
+		// even counts are not recommended
+		{3, 2, false, false},
+		{5, 4, false, false},
+		{4, 2, false, false},
+		// unless they are explicitly allowed
+		{3, 2, true, true},
+		{5, 4, true, true},
+		{2, 2, true, false},
+		// reduce to three mons
+		{5, 3, false, true},
+		{4, 3, false, true},
+		{3, 3, false, false},
+		{2, 3, false, false},
+		// reduce to five mons
+		{7, 5, false, true},
+		{6, 5, false, true},
+		{5, 5, false, false},
+		{3, 5, false, false},
+	}
+
+	for _, test := range tests {
+		msg := fmt.Sprintf("current=%d desired=%d allowEven=%t", test.current, test.desired, test.allowEven)
+		assert.Equal(t, test.expected, canSafelyRemoveMon(test.current, test.desired, test.allowEven), msg)
+	}
+}
+
+func TestCheckHealthEvenMonCount(t *testing.T) {
+	newTwoMonCluster := func(allowEven bool) *Cluster {
+		// three healthy mons while only two are desired
+		status := &client.MonStatusResponse{Quorum: []int{0, 1, 2}}
+		status.MonMap.Mons = []client.MonMapEntry{
+			{Name: "a", Rank: 0, Address: "1.2.3.1"},
+			{Name: "b", Rank: 1, Address: "1.2.3.2"},
+			{Name: "c", Rank: 2, Address: "1.2.3.3"},
+		}
+		configDir, _ := ioutil.TempDir("", "")
+		context := &clusterd.Context{
+			Clientset: test.New(3),
+			ConfigDir: configDir,
+			Executor:  newMonStatusExecutor(status, nil),
+		}
+		monSpec := cephv1.MonSpec{Count: 2, AllowMultiplePerNode: true, AllowEvenMonCount: allowEven}
+		c := New(context, "ns", "", "myversion", cephv1.CephVersionSpec{}, monSpec,
+			rookalpha.Placement{}, false, v1.ResourceRequirements{}, metav1.OwnerReference{})
+		c.clusterInfo = test.CreateConfigDir(3)
+		c.waitForStart = false
+		c.maxMonID = 2
+		return c
+	}
+
+	// the mons are not reduced to an even count by default
+	c := newTwoMonCluster(false)
+	defer os.RemoveAll(c.context.ConfigDir)
+	err := c.checkHealth(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(c.clusterInfo.Monitors))
+
+	// with the override one mon is removed
+	c = newTwoMonCluster(true)
+	defer os.RemoveAll(c.context.ConfigDir)
+	err = c.checkHealth(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(c.clusterInfo.Monitors))
+}
+
+func TestCheckHealthMixedVersions(t *testing.T) {
+	newMixedCluster := func(pause bool) *Cluster {
+		// mon c is out of quorum while the mons are upgraded
+		status := &client.MonStatusResponse{Quorum: []int{0, 1}}
+		status.MonMap.Mons = []client.MonMapEntry{
+			{Name: "a", Rank: 0, Address: "1.2.3.1"},
+			{Name: "b", Rank: 1, Address: "1.2.3.2"},
+			{Name: "c", Rank: 2, Address: "1.2.3.3"},
+		}
+		statusExecutor := newMonStatusExecutor(status, nil)
+		executor := &exectest.MockExecutor{
+			MockExecuteCommandWithOutputFile: func(debug bool, actionName string, command string, outFileArg string, args ...string) (string, error) {
+				if args[0] == "versions" {
+					return `{"mon":{
+						"ceph version 13.2.2 (02899bfda814146b021136e9d8e80eba494e1126) mimic (stable)":2,
+						"ceph version 14.2.1 (d555a9489eb35f84f2e1ef49b77e19da9d113972) nautilus (stable)":1}}`, nil
+				}
+				return statusExecutor.MockExecuteCommandWithOutputFile(debug, actionName, command, outFileArg, args...)
+			},
+		}
+		configDir, _ := ioutil.TempDir("", "")
+		context := &clusterd.Context{
+			Clientset: test.New(3),
+			ConfigDir: configDir,
+			Executor:  executor,
+		}
+		monSpec := cephv1.MonSpec{Count: 3, AllowMultiplePerNode: true, HealthCheck: cephv1.MonHealthCheckSpec{PauseFailoverOnMixedVersions: pause}}
+		c := New(context, "ns", "", "myversion", cephv1.CephVersionSpec{}, monSpec,
+			rookalpha.Placement{}, false, v1.ResourceRequirements{}, metav1.OwnerReference{})
+		c.SetEventRecorder(record.NewFakeRecorder(10))
+		c.clusterInfo = test.CreateConfigDir(3)
+		c.waitForStart = false
+		c.maxMonID = 2
+		c.monTimeoutList["c"] = time.Now().Add(-2 * MonOutTimeout)
+		return c
+	}
+
+	// the failover is paused while the versions are mixed
+	c := newMixedCluster(true)
+	defer os.RemoveAll(c.context.ConfigDir)
+	err := c.checkHealth(ctx)
+	assert.Nil(t, err)
+	assert.True(t, c.MixedVersions())
+	assert.NotNil(t, c.clusterInfo.Monitors["c"])
+	assert.Nil(t, c.clusterInfo.Monitors["d"])
+	recorder := c.recorder.(*record.FakeRecorder)
+	assert.Contains(t, <-recorder.Events, MonMixedVersionsReason)
+
+	// by default the mixed versions are only reported
+	c = newMixedCluster(false)
+	defer os.RemoveAll(c.context.ConfigDir)
+	err = c.checkHealth(ctx)
+	assert.Nil(t, err)
+	assert.True(t, c.MixedVersions())
+	assert.Nil(t, c.clusterInfo.Monitors["c"])
+	assert.NotNil(t, c.clusterInfo.Monitors["d"])
+}
+
+// newMonStatusExecutor returns an executor that reports the given mon status. A mon removed with
+// "mon remove" is dropped from the mon map and the quorum like ceph does, after which removed is
+// called if it is set.
+func newMonStatusExecutor(status *client.MonStatusResponse, removed func(name string)) *exectest.MockExecutor {
+	return &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(debug bool, actionName string, command string, outFileArg string, args ...string) (string, error) {
+			if len(args) > 2 && args[0] == "mon" && args[1] == "remove" {
+				removeFromMonStatus(status, args[2])
+				if removed != nil {
+					removed(args[2])
+				}
+				return "", nil
+			}
+			serialized, _ := json.Marshal(status)
+			return string(serialized), nil
+		},
+	}
+}
+
+func removeFromMonStatus(status *client.MonStatusResponse, name string) {
+	mons := []client.MonMapEntry{}
+	for _, mon := range status.MonMap.Mons {
+		if mon.Name != name {
+			mons = append(mons, mon)
+			continue
+		}
+		quorum := []int{}
+		for _, rank := range status.Quorum {
+			if rank != mon.Rank {
+				quorum = append(quorum, rank)
+			}
+		}
+		status.Quorum = quorum
+	}
+	status.MonMap.Mons = mons
+}
+
+func TestRemoveMonRetryThenConfirm(t *testing.T) {
+	defer func(backoff time.Duration) { RemoveMonBackoff = backoff }(RemoveMonBackoff)
+	RemoveMonBackoff = time.Millisecond
+	status := &client.MonStatusResponse{Quorum: []int{0, 1}}
+	status.MonMap.Mons = []client.MonMapEntry{
+		{Name: "a", Rank: 0, Address: "1.2.3.1"},
+		{Name: "b", Rank: 1, Address: "1.2.3.2"},
+		{Name: "c", Rank: 2, Address: "1.2.3.3"},
+	}
+	// the first attempts to remove the mon fail
+	removeAttempts := 0
+	failedAttempts := 2
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(debug bool, actionName string, command string, outFileArg string, args ...string) (string, error) {
+			if args[0] == "mon" && args[1] == "remove" {
+				removeAttempts++
+				if removeAttempts <= failedAttempts {
+					return "", fmt.Errorf("mock remove failure")
+				}
+				if !monInMonMap(args[2], *status) {
+					return fmt.Sprintf("Error ENOENT: mon.%s does not exist or has already been removed", args[2]), fmt.Errorf("mock exit status 2")
+				}
+				removeFromMonStatus(status, args[2])
+				return "", nil
+			}
+			serialized, _ := json.Marshal(status)
+			return string(serialized), nil
+		},
+	}
+	configDir, _ := ioutil.TempDir("", "")
+	defer os.RemoveAll(configDir)
+	context := &clusterd.Context{Clientset: test.New(3), ConfigDir: configDir, Executor: executor}
+	c := newCluster(context, "ns", false, v1.ResourceRequirements{})
+	c.clusterInfo = test.CreateConfigDir(3)
+	_, err := c.createService(&monConfig{ResourceName: resourceName("c"), DaemonName: "c"})
+	assert.Nil(t, err)
+
+	// the removal is retried until the mon is gone from the mon map
+	err = c.removeMon(ctx, "c", DeadMonGracePeriod, FailoverReasonNotInQuorumTimeout)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, removeAttempts)
+	assert.Nil(t, c.clusterInfo.Monitors["c"])
+	_, err = c.context.Clientset.CoreV1().Services(c.Namespace).Get(resourceName("c"), metav1.GetOptions{})
+	assert.NotNil(t, err)
+
+	// removing a mon that is already gone from the mon map succeeds
+	removeAttempts = 0
+	failedAttempts = 0
+	err = c.removeMonitorFromQuorum("c")
+	assert.Nil(t, err)
+	assert.Equal(t, 1, removeAttempts)
+
+	// the mon stays in the cluster info and keeps its service when the removal is never confirmed
+	removeAttempts = 0
+	failedAttempts = RemoveMonRetries
+	_, err = c.createService(&monConfig{ResourceName: resourceName("b"), DaemonName: "b"})
+	assert.Nil(t, err)
+	err = c.removeMon(ctx, "b", DeadMonGracePeriod, FailoverReasonNotInQuorumTimeout)
+	assert.NotNil(t, err)
+	assert.Equal(t, RemoveMonRetries, removeAttempts)
+	assert.NotNil(t, c.clusterInfo.Monitors["b"])
+	_, err = c.context.Clientset.CoreV1().Services(c.Namespace).Get(resourceName("b"), metav1.GetOptions{})
+	assert.Nil(t, err)
+}
+
+func TestRemoveMonByVersion(t *testing.T) {
+	status := &client.MonStatusResponse{Quorum: []int{0, 1, 2}}
+	status.MonMap.Mons = []client.MonMapEntry{
+		{Name: "a", Rank: 0, Address: "1.2.3.1"},
+		{Name: "b", Rank: 1, Address: "1.2.3.2"},
+		{Name: "c", Rank: 2, Address: "1.2.3.3"},
+	}
+	statusExecutor := newMonStatusExecutor(status, nil)
+	okToRemove := []string{}
+	okToRemoveFails := false
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(debug bool, actionName string, command string, outFileArg string, args ...string) (string, error) {
+			if args[0] == "mon" && args[1] == "ok-to-rm" {
+				okToRemove = append(okToRemove, args[2])
+				if okToRemoveFails {
+					return "Error EBUSY: removing mon." + args[2] + " would break quorum", fmt.Errorf("mock exit status 16")
+				}
+				return "", nil
+			}
+			return statusExecutor.MockExecuteCommandWithOutputFile(debug, actionName, command, outFileArg, args...)
+		},
+	}
+	configDir, _ := ioutil.TempDir("", "")
+	defer os.RemoveAll(configDir)
+	context := &clusterd.Context{Clientset: test.New(3), ConfigDir: configDir, Executor: executor}
+	c := newCluster(context, "ns", false, v1.ResourceRequirements{})
+	c.clusterInfo = test.CreateConfigDir(3)
+
+	// luminous and mimic remove the mon right away
+	c.SetCephVersion(cephver.CephVersion{Major: 13, Minor: 2, Patch: 2})
+	err := c.removeMon(ctx, "c", DeadMonGracePeriod, FailoverReasonNotInQuorumTimeout)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(okToRemove))
+	assert.Nil(t, c.clusterInfo.Monitors["c"])
+
+	// nautilus checks that the mon is safe to remove first
+	c.SetCephVersion(cephver.CephVersion{Major: 14, Minor: 2, Patch: 1})
+	err = c.removeMon(ctx, "b", DeadMonGracePeriod, FailoverReasonNotInQuorumTimeout)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"b"}, okToRemove)
+	assert.Nil(t, c.clusterInfo.Monitors["b"])
+
+	// the mon is kept when it is not safe to remove
+	okToRemoveFails = true
+	err = c.removeMon(ctx, "a", DeadMonGracePeriod, FailoverReasonNotInQuorumTimeout)
+	assert.NotNil(t, err)
+	assert.Equal(t, []string{"b", "a"}, okToRemove)
+	assert.NotNil(t, c.clusterInfo.Monitors["a"])
+}
+
+func TestRemoveMonGracePeriod(t *testing.T) {
+	deleteDeployment := deleteMonDeployment
+	healthyGracePeriod := HealthyMonGracePeriod
+	defer func() {
+		deleteMonDeployment = deleteDeployment
+		HealthyMonGracePeriod = healthyGracePeriod
+	}()
+	gracePeriods := map[string]int64{}
+	deleteMonDeployment = func(clientset kubernetes.Interface, namespace, name string, options *metav1.DeleteOptions) error {
+		gracePeriods[name] = *options.GracePeriodSeconds
+		return nil
+	}
+	HealthyMonGracePeriod = 10 * time.Second
+
+	// four mons in quorum, one more than desired
+	status := &client.MonStatusResponse{Quorum: []int{0, 1, 2, 3}}
+	status.MonMap.Mons = []client.MonMapEntry{
+		{Name: "a", Rank: 0, Address: "1.2.3.1"},
+		{Name: "b", Rank: 1, Address: "1.2.3.2"},
+		{Name: "c", Rank: 2, Address: "1.2.3.3"},
+		{Name: "d", Rank: 3, Address: "1.2.3.4"},
+	}
+	configDir, _ := ioutil.TempDir("", "")
+	defer os.RemoveAll(configDir)
+	context := &clusterd.Context{Clientset: test.New(3), ConfigDir: configDir, Executor: newMonStatusExecutor(status, nil)}
+	c := newCluster(context, "ns", false, v1.ResourceRequirements{})
+	c.clusterInfo = test.CreateConfigDir(4)
+
+	// the extra healthy mon is given time to stop
+	err := c.removeExtraMons(ctx, 3, false, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]int64{"rook-ceph-mon-d": 10}, gracePeriods)
+
+	// a dead mon is removed right away
+	_, err = c.failMon(ctx, 4, 3, false, "a", DeadMonGracePeriod, FailoverReasonNotInQuorumTimeout)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), gracePeriods["rook-ceph-mon-a"])
+
+	// three healthy mons on node0 to node2, with node3 free for a new mon
+	newHealthyCluster := func() (*Cluster, kubernetes.Interface) {
+		status := &client.MonStatusResponse{Quorum: []int{0, 1, 2}}
+		status.MonMap.Mons = []client.MonMapEntry{
+			{Name: "a", Rank: 0, Address: "1.2.3.1"},
+			{Name: "b", Rank: 1, Address: "1.2.3.2"},
+			{Name: "c", Rank: 2, Address: "1.2.3.3"},
+		}
+		clientset := test.New(4)
+		context := &clusterd.Context{Clientset: clientset, ConfigDir: configDir, Executor: newMonStatusExecutor(status, nil)}
+		c := newCluster(context, "ns", false, v1.ResourceRequirements{})
+		c.clusterInfo = test.CreateConfigDir(3)
+		c.maxMonID = 2
+		for i, name := range []string{"a", "b", "c"} {
+			c.mapping.Node[name] = &NodeInfo{Name: fmt.Sprintf("node%d", i)}
+		}
+		gracePeriods = map[string]int64{}
+		return c, clientset
+	}
+
+	// a healthy mon on a node that is not valid anymore is given time to stop when it is moved
+	c, clientset := newHealthyCluster()
+	node, err := clientset.CoreV1().Nodes().Get("node0", metav1.GetOptions{})
+	assert.Nil(t, err)
+	node.Spec.Unschedulable = true
+	_, err = clientset.CoreV1().Nodes().Update(node)
+	assert.Nil(t, err)
+	_, err = c.checkMonsOnValidNodes(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]int64{"rook-ceph-mon-a": 10}, gracePeriods)
+
+	// so is a healthy mon moved to a preferred node
+	c, clientset = newHealthyCluster()
+	node, err = clientset.CoreV1().Nodes().Get("node3", metav1.GetOptions{})
+	assert.Nil(t, err)
+	node.Labels = map[string]string{"mon": "preferred"}
+	_, err = clientset.CoreV1().Nodes().Update(node)
+	assert.Nil(t, err)
+	c.placement = rookalpha.Placement{NodeAffinity: &v1.NodeAffinity{
+		PreferredDuringSchedulingIgnoredDuringExecution: []v1.PreferredSchedulingTerm{
+			{Weight: 1, Preference: v1.NodeSelectorTerm{MatchExpressions: []v1.NodeSelectorRequirement{
+				{Key: "mon", Operator: v1.NodeSelectorOpIn, Values: []string{"preferred"}},
+			}}},
+		},
+	}}
+	err = c.rebalanceToPreferredNode(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]int64{"rook-ceph-mon-a": 10}, gracePeriods)
+}
+
+func TestRemoveMonConnectionConfigFails(t *testing.T) {
+	writeConfig := writeMonConnectionConfig
+	backoff := ConnectionConfigBackoff
+	defer func() {
+		writeMonConnectionConfig = writeConfig
+		ConnectionConfigBackoff = backoff
+	}()
+	ConnectionConfigBackoff = time.Millisecond
+	writes := 0
+	writeErr := fmt.Errorf("disk full")
+	writeMonConnectionConfig = func(context *clusterd.Context, clusterInfo *cephconfig.ClusterInfo) error {
+		writes++
+		return writeErr
+	}
+
+	status := &client.MonStatusResponse{Quorum: []int{0, 1, 2, 3}}
+	status.MonMap.Mons = []client.MonMapEntry{
+		{Name: "a", Rank: 0, Address: "1.2.3.1"},
+		{Name: "b", Rank: 1, Address: "1.2.3.2"},
+		{Name: "c", Rank: 2, Address: "1.2.3.3"},
+		{Name: "d", Rank: 3, Address: "1.2.3.4"},
+	}
+	configDir, _ := ioutil.TempDir("", "")
+	defer os.RemoveAll(configDir)
+	rookClientset := rookfake.NewSimpleClientset(&cephv1.CephCluster{ObjectMeta: metav1.ObjectMeta{Name: "ns", Namespace: "ns"}})
+	context := &clusterd.Context{Clientset: test.New(3), RookClientset: rookClientset, ConfigDir: configDir, Executor: newMonStatusExecutor(status, nil)}
+	c := newCluster(context, "ns", false, v1.ResourceRequirements{})
+	c.ownerRef = metav1.OwnerReference{Name: "ns"}
+	recorder := record.NewFakeRecorder(10)
+	c.SetEventRecorder(recorder)
+	c.clusterInfo = test.CreateConfigDir(4)
+
+	// the removal completes even though the connection config cannot be written
+	err := c.removeMon(ctx, "d", DeadMonGracePeriod, FailoverReasonNotInQuorumTimeout)
+	assert.Nil(t, err)
+	assert.Nil(t, c.clusterInfo.Monitors["d"])
+	assert.Equal(t, ConnectionConfigRetries, writes)
+
+	// the cluster is marked as degraded with a warning event
+	cluster, err := rookClientset.CephV1().CephClusters("ns").Get("ns", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, cephv1.ClusterStateDegraded, cluster.Status.State)
+	assert.Contains(t, cluster.Status.Message, "mon d")
+	events := []string{}
+	for len(recorder.Events) > 0 {
+		events = append(events, <-recorder.Events)
+	}
+	assert.Contains(t, strings.Join(events, "\n"), MonConnectionConfigFailedReason)
+
+	// the write is retried by the next health check and the status is reset once it succeeds
+	writeErr = nil
+	err = c.checkHealth(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, "", c.connConfigMessage)
+	cluster, err = rookClientset.CephV1().CephClusters("ns").Get("ns", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, cephv1.ClusterStateCreated, cluster.Status.State)
+}
+
+func TestRemoveMonMinInQuorum(t *testing.T) {
+	status := &client.MonStatusResponse{Quorum: []int{0, 1, 2, 3}}
+	status.MonMap.Mons = []client.MonMapEntry{
+		{Name: "a", Rank: 0, Address: "1.2.3.1"},
+		{Name: "b", Rank: 1, Address: "1.2.3.2"},
+		{Name: "c", Rank: 2, Address: "1.2.3.3"},
+		{Name: "d", Rank: 3, Address: "1.2.3.4"},
+	}
+	configDir, _ := ioutil.TempDir("", "")
+	defer os.RemoveAll(configDir)
+	context := &clusterd.Context{Clientset: test.New(3), ConfigDir: configDir, Executor: newMonStatusExecutor(status, nil)}
+	c := newCluster(context, "ns", false, v1.ResourceRequirements{})
+	c.clusterInfo = test.CreateConfigDir(4)
+	minInQuorum := MinMonsInQuorum
+	defer func() { MinMonsInQuorum = minInQuorum }()
+	MinMonsInQuorum = 4
+
+	// the extra mon is not removed when fewer than four mons would be left in quorum
+	err := c.removeExtraMons(ctx, 3, false, 1)
+	assert.NotNil(t, err)
+	assert.Equal(t, 4, len(c.clusterInfo.Monitors))
+
+	// a dead mon is not removed when the quorum is already below the floor
+	status.Quorum = []int{0, 1, 2}
+	_, err = c.failMon(ctx, 4, 3, false, "d", DeadMonGracePeriod, FailoverReasonNotInQuorumTimeout)
+	assert.NotNil(t, err)
+	assert.NotNil(t, c.clusterInfo.Monitors["d"])
+
+	// a dead mon can be removed when the mons left in quorum are enough
+	MinMonsInQuorum = 3
+	_, err = c.failMon(ctx, 4, 3, false, "d", DeadMonGracePeriod, FailoverReasonNotInQuorumTimeout)
+	assert.Nil(t, err)
+	assert.Nil(t, c.clusterInfo.Monitors["d"])
+
+	// the floor applies to a mon in quorum
+	err = c.removeMon(ctx, "c", HealthyMonGracePeriod, FailoverReasonExtraMonRemoval)
+	assert.NotNil(t, err)
+	assert.NotNil(t, c.clusterInfo.Monitors["c"])
+
+	// no floor
+	MinMonsInQuorum = 0
+	err = c.removeMon(ctx, "c", HealthyMonGracePeriod, FailoverReasonExtraMonRemoval)
+	assert.Nil(t, err)
+	assert.Nil(t, c.clusterInfo.Monitors["c"])
+}
+
+func TestFailoverMonOrder(t *testing.T) {
+	failover := func(order string, deploymentExists, cordoned bool) []string {
+		status := &client.MonStatusResponse{Quorum: []int{0, 1, 2}}
+		status.MonMap.Mons = []client.MonMapEntry{
+			{Name: "a", Rank: 0, Address: "1.2.3.1"},
+			{Name: "b", Rank: 1, Address: "1.2.3.2"},
+			{Name: "c", Rank: 2, Address: "1.2.3.3"},
+			{Name: "d", Rank: 3, Address: "1.2.3.4"},
+		}
+		steps := []string{}
+		clientset := test.New(3)
+		clientset.PrependReactor("create", "deployments", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			deployment := action.(k8stesting.CreateAction).GetObject().(*extensions.Deployment)
+			steps = append(steps, "add "+deployment.Name)
+			return false, nil, nil
+		})
+		if deploymentExists {
+			d := &extensions.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "rook-ceph-mon-d", Namespace: "ns"}}
+			_, err := clientset.Extensions().Deployments("ns").Create(d)
+			assert.Nil(t, err)
+			steps = []string{}
+		}
+		if cordoned {
+			node, _ := clientset.CoreV1().Nodes().Get("node0", metav1.GetOptions{})
+			node.Spec.Unschedulable = true
+			clientset.CoreV1().Nodes().Update(node)
+		}
+		configDir, _ := ioutil.TempDir("", "")
+		defer os.RemoveAll(configDir)
+		removed := func(name string) { steps = append(steps, "remove "+name) }
+		context := &clusterd.Context{Clientset: clientset, ConfigDir: configDir, Executor: newMonStatusExecutor(status, removed)}
+		c := newCluster(context, "ns", false, v1.ResourceRequirements{})
+		c.clusterInfo = test.CreateConfigDir(4)
+		c.maxMonID = 3
+		c.mapping.Node["d"] = &NodeInfo{Name: "node0"}
+		c.UpdateHealthCheck(cephv1.MonHealthCheckSpec{FailoverOrder: order})
+
+		err := c.failoverMon(ctx, "d", DeadMonGracePeriod, FailoverReasonNotInQuorumTimeout)
+		assert.Nil(t, err)
+		assert.Nil(t, c.clusterInfo.Monitors["d"])
+		assert.NotNil(t, c.clusterInfo.Monitors["e"])
+		return steps
+	}
+
+	// the replacement is started first by default, even for a dead mon
+	assert.Equal(t, []string{"add rook-ceph-mon-e", "remove d"}, failover("", false, false))
+	assert.Equal(t, []string{"add rook-ceph-mon-e", "remove d"}, failover("invalid", false, false))
+
+	// a mon that may still rejoin is not removed first
+	assert.Equal(t, []string{"add rook-ceph-mon-e", "remove d"}, failover(FailoverOrderRemoveDeadFirst, true, false))
+
+	// a mon whose deployment is gone or whose node is cordoned is removed first
+	assert.Equal(t, []string{"remove d", "add rook-ceph-mon-e"}, failover(FailoverOrderRemoveDeadFirst, false, false))
+	assert.Equal(t, []string{"remove d", "add rook-ceph-mon-e"}, failover(FailoverOrderRemoveDeadFirst, true, true))
+}
+
+func TestFailoverMonCallbacks(t *testing.T) {
+	status := &client.MonStatusResponse{Quorum: []int{0, 1, 2}}
+	status.MonMap.Mons = []client.MonMapEntry{
+		{Name: "a", Rank: 0, Address: "1.2.3.1"},
+		{Name: "b", Rank: 1, Address: "1.2.3.2"},
+		{Name: "c", Rank: 2, Address: "1.2.3.3"},
+		{Name: "d", Rank: 3, Address: "1.2.3.4"},
+	}
+	clientset := test.New(3)
+	serviceErr := fmt.Errorf("mock failed to create service")
+	failService := false
+	clientset.PrependReactor("create", "services", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if failService {
+			return true, nil, serviceErr
+		}
+		return false, nil, nil
+	})
+	configDir, _ := ioutil.TempDir("", "")
+	defer os.RemoveAll(configDir)
+	context := &clusterd.Context{Clientset: clientset, ConfigDir: configDir, Executor: newMonStatusExecutor(status, nil)}
+	c := newCluster(context, "ns", false, v1.ResourceRequirements{})
+	c.clusterInfo = test.CreateConfigDir(4)
+	c.maxMonID = 3
+
+	calls := []string{}
+	c.PreFailover = func(monName string) {
+		calls = append(calls, "pre "+monName)
+	}
+	var postErr error
+	c.PostFailover = func(oldName, newName string, err error) {
+		calls = append(calls, fmt.Sprintf("post %s %s", oldName, newName))
+		postErr = err
+	}
+
+	// both callbacks are called around a failover
+	err := c.failoverMon(ctx, "d", DeadMonGracePeriod, FailoverReasonNotInQuorumTimeout)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"pre d", "post d e"}, calls)
+	assert.Nil(t, postErr)
+
+	// the error of a failed failover is passed on
+	calls = []string{}
+	failService = true
+	err = c.failoverMon(ctx, "c", DeadMonGracePeriod, FailoverReasonNotInQuorumTimeout)
+	assert.NotNil(t, err)
+	assert.Equal(t, []string{"pre c", "post c f"}, calls)
+	assert.Equal(t, err, postErr)
+	assert.NotNil(t, c.clusterInfo.Monitors["c"])
+
+	// a failing callback does not stop the failover
+	calls = []string{}
+	failService = false
+	c.PreFailover = func(monName string) {
+		calls = append(calls, "pre "+monName)
+		panic("mock paging failed")
+	}
+	err = c.failoverMon(ctx, "c", DeadMonGracePeriod, FailoverReasonNotInQuorumTimeout)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"pre c", "post c g"}, calls)
+	assert.Nil(t, c.clusterInfo.Monitors["c"])
+	assert.NotNil(t, c.clusterInfo.Monitors["g"])
+
+	// no callbacks
+	c.PreFailover = nil
+	c.PostFailover = nil
+	err = c.failoverMon(ctx, "g", DeadMonGracePeriod, FailoverReasonNotInQuorumTimeout)
+	assert.Nil(t, err)
+}
+
+func TestMonFailoverETA(t *testing.T) {
+	c := newCluster(nil, "ns", false, v1.ResourceRequirements{})
+	now := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	c.clock = &fakeClock{now: now}
+	c.monOutTimeout = 10 * time.Minute
+
+	// a mon that is not out of quorum
+	pending, remaining := c.MonFailoverETA("a")
+	assert.False(t, pending)
+	assert.Equal(t, time.Duration(0), remaining)
+
+	// a mon that was just seen out of quorum
+	c.startMonOutTimeout("b", now.Add(-time.Minute))
+	pending, remaining = c.MonFailoverETA("b")
+	assert.True(t, pending)
+	assert.Equal(t, 9*time.Minute, remaining)
+
+	// a mon past the timeout is failed over by the next health check
+	c.startMonOutTimeout("c", now.Add(-time.Hour))
+	pending, remaining = c.MonFailoverETA("c")
+	assert.True(t, pending)
+	assert.Equal(t, time.Duration(0), remaining)
+
+	// the mon is no longer pending once it is back in quorum
+	c.clearMonOutTimeout("b")
+	pending, _ = c.MonFailoverETA("b")
+	assert.False(t, pending)
+
+	// the timeouts can be read while the health check changes them
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			c.MonFailoverETA("d")
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		c.startMonOutTimeout("d", now)
+		c.clearMonOutTimeout("d")
+	}
+	<-done
+}
+
+func TestCheckStopsOnCancel(t *testing.T) {
+	c := newCluster(&clusterd.Context{}, "ns", false, v1.ResourceRequirements{})
+	c.healthCheckInterval = time.Hour
+	hc := NewHealthChecker(c)
+
+	// the health check loop stops while waiting for the next check
+	cancelCtx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		hc.Check(cancelCtx)
+		close(done)
+	}()
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		assert.Fail(t, "the health check did not stop after the context was cancelled")
+	}
+
+	// no mon is changed with a cancelled context
+	err := c.failoverMon(cancelCtx, "a", DeadMonGracePeriod, FailoverReasonNotInQuorumTimeout)
+	assert.NotNil(t, err)
+	err = c.removeMon(cancelCtx, "a", DeadMonGracePeriod, FailoverReasonNotInQuorumTimeout)
+	assert.NotNil(t, err)
+}
+
+func TestFailMonResult(t *testing.T) {
+	c := newCluster(&clusterd.Context{Clientset: test.New(3)}, "fail-mon-ns", false, v1.ResourceRequirements{})
+	c.ownerRef = metav1.OwnerReference{Name: "my-cluster"}
+	c.clusterInfo = test.CreateConfigDir(3)
+	cancelCtx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// a failover that fails is returned
+	action, err := c.failMon(cancelCtx, 3, 3, false, "a", DeadMonGracePeriod, FailoverReasonNotInQuorumTimeout)
+	assert.Equal(t, monActionFailover, action)
+	assert.NotNil(t, err)
+	assert.Equal(t, float64(1), monActionCount(t, "fail-mon-ns", "my-cluster", monActionFailover, FailoverReasonNotInQuorumTimeout, "failure"))
+
+	// a removal that fails is returned
+	action, err = c.failMon(cancelCtx, 4, 3, false, "a", DeadMonGracePeriod, FailoverReasonNotInQuorumTimeout)
+	assert.Equal(t, monActionRemove, action)
+	assert.NotNil(t, err)
+	assert.Equal(t, float64(1), monActionCount(t, "fail-mon-ns", "my-cluster", monActionRemove, FailoverReasonNotInQuorumTimeout, "failure"))
+	assert.NotNil(t, c.clusterInfo.Monitors["a"])
+
+	// the action is returned in dry-run mode without changing the mons
+	c.dryRun = true
+	action, err = c.failMon(cancelCtx, 3, 3, false, "a", DeadMonGracePeriod, FailoverReasonNotInQuorumTimeout)
+	assert.Equal(t, monActionFailover, action)
+	assert.Nil(t, err)
+	action, err = c.failMon(cancelCtx, 4, 3, false, "a", DeadMonGracePeriod, FailoverReasonNotInQuorumTimeout)
+	assert.Equal(t, monActionRemove, action)
+	assert.Nil(t, err)
+}
+
+func TestCheckHealthFailoverError(t *testing.T) {
+	// mon c is in the mon map but out of quorum
+	status := &client.MonStatusResponse{Quorum: []int{0, 1}}
+	status.MonMap.Mons = []client.MonMapEntry{
+		{Name: "a", Rank: 0, Address: "1.2.3.1"},
+		{Name: "b", Rank: 1, Address: "1.2.3.2"},
+		{Name: "c", Rank: 2, Address: "1.2.3.3"},
+	}
+	clientset := test.New(3)
+	clientset.PrependReactor("create", "services", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, fmt.Errorf("mock failed to create service")
+	})
+	configDir, _ := ioutil.TempDir("", "")
+	defer os.RemoveAll(configDir)
+	context := &clusterd.Context{
+		Clientset: clientset,
+		ConfigDir: configDir,
+		Executor:  newMonStatusExecutor(status, nil),
+	}
+	c := New(context, "failover-error-ns", "", "myversion", cephv1.CephVersionSpec{}, cephv1.MonSpec{Count: 3, AllowMultiplePerNode: true},
+		rookalpha.Placement{}, false, v1.ResourceRequirements{}, metav1.OwnerReference{Name: "my-cluster"})
+	c.clusterInfo = test.CreateConfigDir(3)
+	c.waitForStart = false
+	c.maxMonID = 2
+	c.monTimeoutList["c"] = time.Now().Add(-2 * MonOutTimeout)
+
+	// the failed failover is returned by the health check instead of passing as a success
+	err := c.checkHealth(ctx)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "failed to failover mon c")
+	assert.NotNil(t, c.clusterInfo.Monitors["c"])
+	assert.Equal(t, float64(1), monActionCount(t, "failover-error-ns", "my-cluster", monActionFailover, FailoverReasonNotInQuorumTimeout, "failure"))
+	assert.Equal(t, float64(0), monActionCount(t, "failover-error-ns", "my-cluster", monActionFailover, FailoverReasonNotInQuorumTimeout, "success"))
+}
+
+func TestCheckHealthFailoverReason(t *testing.T) {
+	healthCheck := func(namespace string, status *client.MonStatusResponse, monCount int, timedOut string) []string {
+		configDir, _ := ioutil.TempDir("", "")
+		defer os.RemoveAll(configDir)
+		context := &clusterd.Context{Clientset: test.New(3), ConfigDir: configDir, Executor: newMonStatusExecutor(status, nil)}
+		c := newCluster(context, namespace, false, v1.ResourceRequirements{})
+		c.ownerRef = metav1.OwnerReference{Name: "my-cluster"}
+		recorder := record.NewFakeRecorder(10)
+		c.SetEventRecorder(recorder)
+		c.clusterInfo = test.CreateConfigDir(monCount)
+		c.maxMonID = monCount - 1
+		if timedOut != "" {
+			c.monTimeoutList[timedOut] = time.Now().Add(-2 * MonOutTimeout)
+		}
+
+		err := c.checkHealth(ctx)
+		assert.Nil(t, err)
+		events := []string{}
+		for len(recorder.Events) > 0 {
+			events = append(events, <-recorder.Events)
+		}
+		return events
+	}
+
+	// mon c is out of quorum past the timeout
+	status := &client.MonStatusResponse{Quorum: []int{0, 1}}
+	status.MonMap.Mons = []client.MonMapEntry{
+		{Name: "a", Rank: 0, Address: "1.2.3.1"},
+		{Name: "b", Rank: 1, Address: "1.2.3.2"},
+		{Name: "c", Rank: 2, Address: "1.2.3.3"},
+	}
+	events := healthCheck("reason-quorum-ns", status, 3, "c")
+	assert.Contains(t, events, "Normal MonFailover failed over mon c to new mon d, reason: NotInQuorumTimeout")
+	assert.Equal(t, float64(1), monActionCount(t, "reason-quorum-ns", "my-cluster", monActionFailover, FailoverReasonNotInQuorumTimeout, "success"))
+
+	// mon d is expected but not in the mon map
+	status = &client.MonStatusResponse{Quorum: []int{0, 1, 2}}
+	status.MonMap.Mons = []client.MonMapEntry{
+		{Name: "a", Rank: 0, Address: "1.2.3.1"},
+		{Name: "b", Rank: 1, Address: "1.2.3.2"},
+		{Name: "c", Rank: 2, Address: "1.2.3.3"},
+	}
+	events = healthCheck("reason-monmap-ns", status, 4, "")
+	assert.Contains(t, events, "Normal MonRemoved removed mon d, reason: MissingFromMonMap")
+	assert.Equal(t, float64(1), monActionCount(t, "reason-monmap-ns", "my-cluster", monActionRemove, FailoverReasonMissingFromMonMap, "success"))
+
+	// mon d is healthy but more mons are running than desired
+	status = &client.MonStatusResponse{Quorum: []int{0, 1, 2, 3}}
+	status.MonMap.Mons = []client.MonMapEntry{
+		{Name: "a", Rank: 0, Address: "1.2.3.1"},
+		{Name: "b", Rank: 1, Address: "1.2.3.2"},
+		{Name: "c", Rank: 2, Address: "1.2.3.3"},
+		{Name: "d", Rank: 3, Address: "1.2.3.4"},
+	}
+	events = healthCheck("reason-extra-ns", status, 4, "")
+	assert.Contains(t, events, "Normal MonRemoved removed mon d, reason: ExtraMonRemoval")
+	assert.Equal(t, float64(1), monActionCount(t, "reason-extra-ns", "my-cluster", monActionRemove, FailoverReasonExtraMonRemoval, "success"))
+}
+
+func TestCheckHealthDryRun(t *testing.T) {
+	// mon c is in the mon map but out of quorum
+	status := &client.MonStatusResponse{Quorum: []int{0, 1}}
+	status.MonMap.Mons = []client.MonMapEntry{
+		{Name: "a", Rank: 0, Address: "1.2.3.1"},
+		{Name: "b", Rank: 1, Address: "1.2.3.2"},
+		{Name: "c", Rank: 2, Address: "1.2.3.3"},
+	}
+	executor := newMonStatusExecutor(status, func(name string) {
+		assert.Fail(t, fmt.Sprintf("mon %s removed in dry-run mode", name))
+	})
+	clientset := test.New(3)
+	rookClientset := rookfake.NewSimpleClientset(&cephv1.CephCluster{ObjectMeta: metav1.ObjectMeta{Name: "my-cluster", Namespace: "dry-run-ns"}})
+	configDir, _ := ioutil.TempDir("", "")
+	defer os.RemoveAll(configDir)
+	context := &clusterd.Context{
+		Clientset:     clientset,
+		RookClientset: rookClientset,
+		ConfigDir:     configDir,
+		Executor:      executor,
+	}
+	monSpec := cephv1.MonSpec{Count: 3, AllowMultiplePerNode: true, HealthCheck: cephv1.MonHealthCheckSpec{DryRun: true}}
+	c := New(context, "dry-run-ns", "", "myversion", cephv1.CephVersionSpec{}, monSpec,
+		rookalpha.Placement{}, false, v1.ResourceRequirements{}, metav1.OwnerReference{Name: "my-cluster"})
+	recorder := record.NewFakeRecorder(10)
+	c.SetEventRecorder(recorder)
+	c.clusterInfo = test.CreateConfigDir(3)
+	c.waitForStart = false
+	c.maxMonID = 2
+	c.monTimeoutList["c"] = time.Now().Add(-2 * MonOutTimeout)
+
+	// the mon would be failed over, but nothing is changed
+	clientset.ClearActions()
+	err := c.checkHealth(ctx)
+	assert.Nil(t, err)
+	assert.NotNil(t, c.clusterInfo.Monitors["c"])
+	assert.Nil(t, c.clusterInfo.Monitors["d"])
+	assert.Equal(t, float64(1), dryRunActionCount(t, "dry-run-ns", "my-cluster", monActionFailover))
+
+	// the mons would be increased to the desired count
+	removeFromMonStatus(status, "c")
+	delete(c.clusterInfo.Monitors, "c")
+	c.Count = 5
+	err = c.checkHealth(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(c.clusterInfo.Monitors))
+	assert.Equal(t, float64(1), dryRunActionCount(t, "dry-run-ns", "my-cluster", monActionAdd))
+
+	// an invalid mon count does not change the cluster status
+	c.Count = 0
+	err = c.checkHealth(ctx)
+	assert.NotNil(t, err)
+	c.Count = 5
+
+	// neither does an unreachable quorum
+	monStatus := c.monStatus
+	c.monStatus = &fakeMonStatus{err: fmt.Errorf("mock timeout")}
+	err = c.checkHealth(ctx)
+	assert.NotNil(t, err)
+	c.monStatus = monStatus
+
+	// nor a stopped failover of mon c, which is missing from the mon map
+	c.clusterInfo.Monitors["c"] = cephconfig.NewMonInfo("c", "1.2.3.3", 6790)
+	c.failoverAttempts["c"] = DefaultMaxFailoverAttempts
+	err = c.checkHealth(ctx)
+	assert.Nil(t, err)
+	delete(c.failoverAttempts, "c")
+
+	// no changes are made to any resources or to the cluster status
+	for _, action := range clientset.Actions() {
+		verb := action.GetVerb()
+		assert.True(t, verb == "get" || verb == "list", fmt.Sprintf("unexpected %s of %s", verb, action.GetResource().Resource))
+	}
+	for _, action := range rookClientset.Actions() {
+		assert.Equal(t, "get", action.GetVerb())
+	}
+	cluster, err := rookClientset.CephV1().CephClusters("dry-run-ns").Get("my-cluster", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, cephv1.ClusterState(""), cluster.Status.State)
+	assert.Equal(t, "", cluster.Status.Message)
+	assert.Equal(t, 0, len(cluster.Status.Conditions))
+	assert.Equal(t, 0, len(recorder.Events))
+
+	// the mon is failed over once dry-run mode is turned off
+	c.Count = 3
+	status.MonMap.Mons = append(status.MonMap.Mons, client.MonMapEntry{Name: "c", Rank: 2, Address: "1.2.3.3"})
+	c.clusterInfo = test.CreateConfigDir(3)
+	c.UpdateHealthCheck(cephv1.MonHealthCheckSpec{})
+	executor.MockExecuteCommandWithOutputFile = newMonStatusExecutor(status, nil).MockExecuteCommandWithOutputFile
+	err = c.checkHealth(ctx)
+	assert.Nil(t, err)
+	assert.Nil(t, c.clusterInfo.Monitors["c"])
+	assert.NotNil(t, c.clusterInfo.Monitors["d"])
+}
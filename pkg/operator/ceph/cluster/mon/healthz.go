@@ -0,0 +1,131 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mon
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const (
+	// HealthzPath is the path the health of the mons of the clusters is served on
+	HealthzPath = "/healthz/mons"
+	// MetricsPath is the path the metrics of the mons of the clusters are served on for prometheus
+	MetricsPath = "/metrics"
+)
+
+// MonHealthState is the outcome of the latest health check of the mons of a cluster
+type MonHealthState struct {
+	Namespace string `json:"namespace"`
+	Cluster   string `json:"cluster"`
+	// InQuorum is the number of mons in quorum
+	InQuorum int `json:"inQuorum"`
+	// Desired is the desired number of mons
+	Desired int `json:"desired"`
+	// FailoverInProgress is true while a mon that replaced a failed mon has not joined quorum yet
+	FailoverInProgress bool `json:"failoverInProgress"`
+	// QuorumUnreachable is true when the status of the mons could not be retrieved
+	QuorumUnreachable bool `json:"quorumUnreachable"`
+	// LastInQuorum is the last time each mon in the mon map was seen in quorum, to debug flapping mons
+	LastInQuorum map[string]time.Time `json:"lastInQuorum,omitempty"`
+	// LastCheck is the time of the health check
+	LastCheck time.Time `json:"lastCheck"`
+}
+
+var (
+	// the latest health state of the mons of each cluster, by namespace
+	healthStates     = map[string]MonHealthState{}
+	healthStatesLock sync.RWMutex
+)
+
+// setHealthState caches the outcome of the health check for the health endpoint
+func (c *Cluster) setHealthState(inQuorum, desired int, unreachable bool) {
+	state := MonHealthState{
+		Namespace:          c.Namespace,
+		Cluster:            c.ownerRef.Name,
+		InQuorum:           inQuorum,
+		Desired:            desired,
+		FailoverInProgress: len(c.failoverAttempts) > 0,
+		QuorumUnreachable:  unreachable,
+		LastCheck:          c.clock.Now(),
+	}
+	if len(c.lastInQuorum) > 0 {
+		state.LastInQuorum = map[string]time.Time{}
+		for name, seen := range c.lastInQuorum {
+			state.LastInQuorum[name] = seen
+		}
+	}
+	healthStatesLock.Lock()
+	defer healthStatesLock.Unlock()
+	healthStates[c.Namespace] = state
+}
+
+// removeHealthState forgets the health state of the mons when the health check of the cluster stops
+func removeHealthState(namespace string) {
+	healthStatesLock.Lock()
+	defer healthStatesLock.Unlock()
+	delete(healthStates, namespace)
+}
+
+// HealthzHandler serves the latest health state of the mons of every cluster as JSON, sorted by namespace.
+// The states are cached by the health checks, so no request reaches ceph. The status code is 503 when
+// the quorum of a cluster is unreachable or fewer than a majority of its desired mons are in quorum.
+func HealthzHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		healthStatesLock.RLock()
+		states := make([]MonHealthState, 0, len(healthStates))
+		for _, state := range healthStates {
+			states = append(states, state)
+		}
+		healthStatesLock.RUnlock()
+		sort.Slice(states, func(i, j int) bool { return states[i].Namespace < states[j].Namespace })
+
+		body, err := json.Marshal(states)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if !quorumHealthy(states) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		w.Write(body)
+	})
+}
+
+// NewHealthzMux serves the health of the mons on HealthzPath and the metrics of the mons on MetricsPath
+func NewHealthzMux() *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.Handle(HealthzPath, HealthzHandler())
+	mux.Handle(MetricsPath, promhttp.Handler())
+	return mux
+}
+
+// quorumHealthy checks that a majority of the desired mons are in quorum in every cluster
+func quorumHealthy(states []MonHealthState) bool {
+	for _, state := range states {
+		if state.QuorumUnreachable || state.InQuorum <= state.Desired/2 {
+			return false
+		}
+	}
+	return true
+}
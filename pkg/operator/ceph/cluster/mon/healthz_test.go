@@ -0,0 +1,103 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mon
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/rook/rook/pkg/daemon/ceph/client"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestHealthzHandler(t *testing.T) {
+	defer func() {
+		removeHealthState("ns1")
+		removeHealthState("ns2")
+	}()
+	get := func() *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		HealthzHandler().ServeHTTP(recorder, httptest.NewRequest("GET", HealthzPath, nil))
+		return recorder
+	}
+
+	// the state is seeded by the health checks of two clusters
+	now := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	c1 := newCluster(nil, "ns1", false, v1.ResourceRequirements{})
+	c1.ownerRef = metav1.OwnerReference{Name: "cluster1"}
+	c1.clock = &fakeClock{now: now}
+	c1.setHealthState(3, 3, false)
+	c2 := newCluster(nil, "ns2", false, v1.ResourceRequirements{})
+	c2.ownerRef = metav1.OwnerReference{Name: "cluster2"}
+	c2.clock = &fakeClock{now: now}
+	c2.failoverAttempts["d"] = 1
+	c2.setHealthState(2, 3, false)
+
+	response := get()
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.Equal(t, "application/json", response.Header().Get("Content-Type"))
+	assert.JSONEq(t, `[
+		{"namespace": "ns1", "cluster": "cluster1", "inQuorum": 3, "desired": 3, "failoverInProgress": false, "quorumUnreachable": false, "lastCheck": "2019-01-01T00:00:00Z"},
+		{"namespace": "ns2", "cluster": "cluster2", "inQuorum": 2, "desired": 3, "failoverInProgress": true, "quorumUnreachable": false, "lastCheck": "2019-01-01T00:00:00Z"}
+	]`, response.Body.String())
+
+	// the quorum of a cluster is lost
+	c2.setHealthState(1, 3, false)
+	assert.Equal(t, http.StatusServiceUnavailable, get().Code)
+	c2.setHealthState(0, 3, true)
+	assert.Equal(t, http.StatusServiceUnavailable, get().Code)
+
+	// the state is forgotten once the health check stops
+	removeHealthState("ns2")
+	response = get()
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.Contains(t, response.Body.String(), "ns1")
+	assert.NotContains(t, response.Body.String(), "ns2")
+}
+
+func TestHealthzMuxMetrics(t *testing.T) {
+	server := httptest.NewServer(NewHealthzMux())
+	defer server.Close()
+
+	// the metrics of a health check are scraped from the endpoint
+	c := newCluster(nil, "scrape-ns", false, v1.ResourceRequirements{})
+	c.ownerRef = metav1.OwnerReference{Name: "my-cluster"}
+	status := client.MonStatusResponse{Quorum: []int{0, 1}}
+	status.MonMap.Mons = []client.MonMapEntry{{Name: "a", Rank: 0}, {Name: "b", Rank: 1}, {Name: "c", Rank: 2}}
+	c.updateMetrics(3, status)
+
+	response, err := http.Get(server.URL + MetricsPath)
+	assert.Nil(t, err)
+	defer response.Body.Close()
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	body, err := ioutil.ReadAll(response.Body)
+	assert.Nil(t, err)
+	assert.Contains(t, string(body), `rook_ceph_mon_desired{cluster="my-cluster",namespace="scrape-ns"} 3`)
+	assert.Contains(t, string(body), `rook_ceph_mon_in_quorum{cluster="my-cluster",namespace="scrape-ns"} 2`)
+	assert.Contains(t, string(body), `rook_ceph_mon_in_monmap{cluster="my-cluster",namespace="scrape-ns"} 3`)
+
+	// the health of the mons is served on the same endpoint
+	response, err = http.Get(server.URL + HealthzPath)
+	assert.Nil(t, err)
+	response.Body.Close()
+	assert.Equal(t, "application/json", response.Header.Get("Content-Type"))
+}
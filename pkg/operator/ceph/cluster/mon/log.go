@@ -0,0 +1,51 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mon
+
+import (
+	"fmt"
+)
+
+// clusterLogger logs through the package logger with the identity of the cluster in front of each
+// message, so the logs of several clusters managed by the same operator can be told apart. The zero
+// value logs without a prefix.
+type clusterLogger struct {
+	prefix string
+}
+
+func newClusterLogger(namespace, name string) clusterLogger {
+	if name == "" || name == namespace {
+		return clusterLogger{prefix: fmt.Sprintf("[%s] ", namespace)}
+	}
+	return clusterLogger{prefix: fmt.Sprintf("[%s/%s] ", namespace, name)}
+}
+
+func (l clusterLogger) Debugf(format string, args ...interface{}) {
+	logger.Debugf(l.prefix+format, args...)
+}
+
+func (l clusterLogger) Infof(format string, args ...interface{}) {
+	logger.Infof(l.prefix+format, args...)
+}
+
+func (l clusterLogger) Warningf(format string, args ...interface{}) {
+	logger.Warningf(l.prefix+format, args...)
+}
+
+func (l clusterLogger) Errorf(format string, args ...interface{}) {
+	logger.Errorf(l.prefix+format, args...)
+}
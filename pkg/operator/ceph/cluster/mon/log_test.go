@@ -0,0 +1,56 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mon
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/coreos/pkg/capnslog"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/api/core/v1"
+)
+
+func TestClusterLogger(t *testing.T) {
+	var buf bytes.Buffer
+	capnslog.SetFormatter(capnslog.NewStringFormatter(&buf))
+	defer capnslog.SetFormatter(capnslog.NewPrettyFormatter(os.Stderr, false))
+
+	// the name is left out when it is the same as the namespace
+	c := newCluster(&clusterd.Context{}, "ns", false, v1.ResourceRequirements{})
+	c.log.Infof("mon %s not in quorum", "a")
+	assert.Contains(t, buf.String(), "[ns] mon a not in quorum")
+
+	buf.Reset()
+	c.log = newClusterLogger("ns", "ns")
+	c.log.Warningf("mon %s not in quorum", "b")
+	assert.Contains(t, buf.String(), "[ns] mon b not in quorum")
+
+	buf.Reset()
+	c.log = newClusterLogger("ns", "mycluster")
+	c.log.Errorf("mon %s not in quorum", "c")
+	assert.Contains(t, buf.String(), "[ns/mycluster] mon c not in quorum")
+
+	// the zero value logs without a prefix
+	buf.Reset()
+	var l clusterLogger
+	l.Infof("mon %s not in quorum", "d")
+	assert.Contains(t, buf.String(), "mon d not in quorum")
+	assert.NotContains(t, buf.String(), "[")
+}
@@ -0,0 +1,97 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mon
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/rook/rook/pkg/daemon/ceph/client"
+)
+
+const (
+	metricsNamespace = "rook"
+	metricsSubsystem = "ceph_mon"
+)
+
+var (
+	// the labels distinguish the mons of multiple clusters managed by the operator
+	metricLabels = []string{"namespace", "cluster"}
+
+	monDesiredGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Subsystem: metricsSubsystem,
+		Name:      "desired",
+		Help:      "Number of mons desired in the cluster",
+	}, metricLabels)
+	monQuorumGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Subsystem: metricsSubsystem,
+		Name:      "in_quorum",
+		Help:      "Number of mons in quorum",
+	}, metricLabels)
+	monMapGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Subsystem: metricsSubsystem,
+		Name:      "in_monmap",
+		Help:      "Number of mons in the ceph mon map",
+	}, metricLabels)
+	monOutTimeoutGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Subsystem: metricsSubsystem,
+		Name:      "out_of_quorum",
+		Help:      "Number of mons out of quorum that are waiting for the out timeout before failing over",
+	}, metricLabels)
+	monDryRunActions = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Subsystem: metricsSubsystem,
+		Name:      "dry_run_actions_total",
+		Help:      "Number of mon failovers, removals and additions the health check would have made in dry-run mode",
+	}, append(metricLabels, "action"))
+	monActions = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Subsystem: metricsSubsystem,
+		Name:      "actions_total",
+		Help:      "Number of mon failovers and removals made by the health check, by the reason and whether they succeeded",
+	}, append(metricLabels, "action", "reason", "result"))
+)
+
+func init() {
+	prometheus.MustRegister(monDesiredGauge, monQuorumGauge, monMapGauge, monOutTimeoutGauge, monDryRunActions, monActions)
+}
+
+// updateMetrics sets the mon gauges of the cluster from the latest health check
+func (c *Cluster) updateMetrics(desiredMonCount int, status client.MonStatusResponse) {
+	labels := prometheus.Labels{"namespace": c.Namespace, "cluster": c.ownerRef.Name}
+	monDesiredGauge.With(labels).Set(float64(desiredMonCount))
+	monQuorumGauge.With(labels).Set(float64(len(status.Quorum)))
+	monMapGauge.With(labels).Set(float64(len(status.MonMap.Mons)))
+	monOutTimeoutGauge.With(labels).Set(float64(len(c.monTimeoutList)))
+}
+
+// recordDryRunAction logs an action the health check would take in dry-run mode and counts it
+func (c *Cluster) recordDryRunAction(action, messageFmt string, args ...interface{}) {
+	c.log.Infof("dry run: "+messageFmt, args...)
+	monDryRunActions.WithLabelValues(c.Namespace, c.ownerRef.Name, action).Inc()
+}
+
+// recordMonAction counts a failover or removal of a mon by its reason and whether it failed
+func (c *Cluster) recordMonAction(action string, reason FailoverReason, err error) {
+	result := "success"
+	if err != nil {
+		result = "failure"
+	}
+	monActions.WithLabelValues(c.Namespace, c.ownerRef.Name, action, string(reason), result).Inc()
+}
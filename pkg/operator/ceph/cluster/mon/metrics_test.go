@@ -0,0 +1,87 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mon
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/daemon/ceph/client"
+	"github.com/rook/rook/pkg/operator/test"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func gaugeValue(t *testing.T, gauge *prometheus.GaugeVec, namespace, cluster string) float64 {
+	metric := &dto.Metric{}
+	err := gauge.WithLabelValues(namespace, cluster).Write(metric)
+	assert.Nil(t, err)
+	return metric.GetGauge().GetValue()
+}
+
+func TestHealthCheckMetrics(t *testing.T) {
+	// mon c is in the mon map but out of quorum
+	resp := client.MonStatusResponse{Quorum: []int{0, 1}}
+	resp.MonMap.Mons = []client.MonMapEntry{
+		{Name: "a", Rank: 0, Address: "1.2.3.1"},
+		{Name: "b", Rank: 1, Address: "1.2.3.2"},
+		{Name: "c", Rank: 2, Address: "1.2.3.3"},
+	}
+	serialized, _ := json.Marshal(resp)
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(debug bool, actionName string, command string, outFileArg string, args ...string) (string, error) {
+			return string(serialized), nil
+		},
+	}
+	configDir, _ := ioutil.TempDir("", "")
+	defer os.RemoveAll(configDir)
+	context := &clusterd.Context{Clientset: test.New(3), ConfigDir: configDir, Executor: executor}
+	c := newCluster(context, "metrics-ns", false, v1.ResourceRequirements{})
+	c.ownerRef = metav1.OwnerReference{Name: "my-cluster"}
+	c.clusterInfo = test.CreateConfigDir(3)
+
+	err := c.checkHealth(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, float64(3), gaugeValue(t, monDesiredGauge, "metrics-ns", "my-cluster"))
+	assert.Equal(t, float64(2), gaugeValue(t, monQuorumGauge, "metrics-ns", "my-cluster"))
+	assert.Equal(t, float64(3), gaugeValue(t, monMapGauge, "metrics-ns", "my-cluster"))
+	assert.Equal(t, float64(1), gaugeValue(t, monOutTimeoutGauge, "metrics-ns", "my-cluster"))
+
+	// the gauges of another cluster are not affected
+	assert.Equal(t, float64(0), gaugeValue(t, monDesiredGauge, "other-ns", "my-cluster"))
+}
+
+func dryRunActionCount(t *testing.T, namespace, cluster, action string) float64 {
+	metric := &dto.Metric{}
+	err := monDryRunActions.WithLabelValues(namespace, cluster, action).Write(metric)
+	assert.Nil(t, err)
+	return metric.GetCounter().GetValue()
+}
+
+func monActionCount(t *testing.T, namespace, cluster, action string, reason FailoverReason, result string) float64 {
+	metric := &dto.Metric{}
+	err := monActions.WithLabelValues(namespace, cluster, action, string(reason), result).Write(metric)
+	assert.Nil(t, err)
+	return metric.GetCounter().GetValue()
+}
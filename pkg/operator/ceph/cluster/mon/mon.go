@@ -22,6 +22,7 @@ package mon
 import (
 	"encoding/json"
 	"fmt"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -34,12 +35,14 @@ import (
 	"github.com/rook/rook/pkg/daemon/ceph/client"
 	cephconfig "github.com/rook/rook/pkg/daemon/ceph/config"
 	mondaemon "github.com/rook/rook/pkg/daemon/ceph/mon"
+	cephver "github.com/rook/rook/pkg/operator/ceph/version"
 	"github.com/rook/rook/pkg/operator/k8sutil"
 	"github.com/rook/rook/pkg/util"
 	"k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/kubernetes/pkg/kubelet/apis"
 )
 
@@ -54,6 +57,15 @@ const (
 	MaxMonIDKey = "maxMonId"
 	// MappingKey is the name of the mapping for the mon->node and node->port
 	MappingKey = "mapping"
+	// OutTimeoutsKey is the name of the times the mons were first seen out of quorum
+	OutTimeoutsKey = "outTimeouts"
+	// CephVersionKey is the name of the highest ceph version the mons ran
+	CephVersionKey = "cephVersion"
+	// AllowDowngradeAnnotation is the annotation of the cluster CRD that allows the mons to run a lower ceph
+	// version than they already ran
+	AllowDowngradeAnnotation = "ceph.rook.io/allow-downgrade"
+	// DowngradeBlockedReason is the reason of the event and the condition when a downgrade is refused
+	DowngradeBlockedReason = "DowngradeBlocked"
 
 	appName           = "rook-ceph-mon"
 	monNodeAttr       = "mon_node"
@@ -66,6 +78,9 @@ const (
 
 	// DefaultMonCount Default mon count for a cluster
 	DefaultMonCount = 3
+)
+
+var (
 	// MaxMonCount Maximum allowed mon count for a cluster
 	MaxMonCount = 9
 )
@@ -77,22 +92,61 @@ type Cluster struct {
 	Keyring              string
 	rookVersion          string
 	cephVersion          cephv1.CephVersionSpec
+	detectedVersion      cephver.CephVersion
+	lastVersion          cephver.CephVersion
 	Count                int
 	AllowMultiplePerNode bool
+	AllowEvenMonCount    bool
 	MonCountMutex        sync.Mutex
+	orchestrationMutex   sync.Mutex
 	Port                 int32
 	clusterInfo          *cephconfig.ClusterInfo
 	placement            rookalpha.Placement
 	maxMonID             int
+	monIDMutex           sync.Mutex
 	waitForStart         bool
 	dataDirHostPath      string
 	monPodRetryInterval  time.Duration
 	monPodTimeout        time.Duration
 	monTimeoutList       map[string]time.Time
+	monTimeoutMutex      sync.RWMutex
+	lastInQuorum         map[string]time.Time
+	healthCheckInterval  time.Duration
+	monOutTimeout        time.Duration
+	maxFailovers         int
+	maxRemovals          int
+	suspendFailoverUntil time.Time
+	topologyKey          string
+	dryRun               bool
+	maxFailoverAttempts  int
+	failoverAttempts     map[string]int
+	failoverBackoff      map[string]*monFailoverBackoff
+	pauseOnMixedVersions bool
+	deferOnHealthErr     bool
+	rebalancePreferred   bool
+	allowMonRemoval      bool
+	failoverOrder        string
+	mixedVersions        bool
+	excludedNodes        map[string]time.Time
+	monCountMessage      string
+	connConfigMessage    string
+	quorumUnreachable    bool
+	monsCondition        cephv1.ClusterCondition
 	HostNetwork          bool
 	mapping              *Mapping
 	resources            v1.ResourceRequirements
 	ownerRef             metav1.OwnerReference
+	recorder             record.EventRecorder
+	log                  clusterLogger
+	clock                clock
+	monStatus            MonStatusGetter
+
+	// PreFailover is called with the name of a mon before the mon is failed over, such as to notify external
+	// tooling. It must not block.
+	PreFailover func(monName string)
+	// PostFailover is called after the failover of a mon with the name of the new mon, which is empty if the
+	// failover failed before the new mon was named, and the error of the failover
+	PostFailover func(oldName, newName string, err error)
 }
 
 // monConfig for a single monitor
@@ -132,11 +186,30 @@ func New(context *clusterd.Context, namespace, dataDirHostPath, rookVersion stri
 		cephVersion:          cephVersion,
 		Count:                mon.Count,
 		AllowMultiplePerNode: mon.AllowMultiplePerNode,
+		AllowEvenMonCount:    mon.AllowEvenMonCount,
+		lastVersion:          cephver.UnknownVersion(),
 		maxMonID:             -1,
 		waitForStart:         true,
 		monPodRetryInterval:  6 * time.Second,
 		monPodTimeout:        5 * time.Minute,
 		monTimeoutList:       map[string]time.Time{},
+		lastInQuorum:         map[string]time.Time{},
+		healthCheckInterval:  parseHealthCheckDuration("interval", mon.HealthCheck.Interval, HealthCheckInterval),
+		monOutTimeout:        parseHealthCheckDuration("timeout", mon.HealthCheck.Timeout, MonOutTimeout),
+		maxFailovers:         parseMaxConcurrentFailover(mon.HealthCheck.MaxConcurrentFailover),
+		maxRemovals:          parseMaxConcurrentRemoval(mon.HealthCheck.MaxConcurrentRemoval),
+		suspendFailoverUntil: parseSuspendFailoverUntil(mon.HealthCheck.SuspendFailoverUntil, time.Now()),
+		topologyKey:          monTopologyKey(mon.TopologyKey),
+		dryRun:               mon.HealthCheck.DryRun,
+		maxFailoverAttempts:  parseMaxFailoverAttempts(mon.HealthCheck.MaxFailoverAttempts),
+		failoverAttempts:     map[string]int{},
+		failoverBackoff:      map[string]*monFailoverBackoff{},
+		pauseOnMixedVersions: mon.HealthCheck.PauseFailoverOnMixedVersions,
+		deferOnHealthErr:     mon.HealthCheck.DeferFailoverOnHealthErr,
+		rebalancePreferred:   mon.HealthCheck.RebalanceToPreferredNodes,
+		allowMonRemoval:      parseAllowMonRemoval(mon.HealthCheck.AllowMonRemoval),
+		failoverOrder:        parseFailoverOrder(mon.HealthCheck.FailoverOrder),
+		excludedNodes:        map[string]time.Time{},
 		HostNetwork:          hostNetwork,
 		mapping: &Mapping{
 			Node: map[string]*NodeInfo{},
@@ -144,11 +217,39 @@ func New(context *clusterd.Context, namespace, dataDirHostPath, rookVersion stri
 		},
 		resources: resources,
 		ownerRef:  ownerRef,
+		log:       newClusterLogger(namespace, ownerRef.Name),
+		clock:     realClock{},
+		monStatus: cephMonStatus{},
 	}
 }
 
+// Update applies the settings of an updated cluster CRD to the mons. The state of the health check, such as
+// the mon out timeouts and the detected ceph version, is kept for the running health checker. Start must be
+// called afterward to update the mons.
+func (c *Cluster) Update(rookVersion string, cephVersion cephv1.CephVersionSpec, mon cephv1.MonSpec,
+	placement rookalpha.Placement, hostNetwork bool, resources v1.ResourceRequirements) {
+	c.orchestrationMutex.Lock()
+	c.MonCountMutex.Lock()
+	c.rookVersion = rookVersion
+	c.cephVersion = cephVersion
+	c.Count = mon.Count
+	c.AllowMultiplePerNode = mon.AllowMultiplePerNode
+	c.AllowEvenMonCount = mon.AllowEvenMonCount
+	c.topologyKey = monTopologyKey(mon.TopologyKey)
+	c.placement = placement
+	c.HostNetwork = hostNetwork
+	c.resources = resources
+	c.MonCountMutex.Unlock()
+	c.orchestrationMutex.Unlock()
+
+	c.UpdateHealthCheck(mon.HealthCheck)
+}
+
 // Start begins the process of running a cluster of Ceph mons.
 func (c *Cluster) Start() error {
+	// the mons are not changed by the health check while they are started
+	c.orchestrationMutex.Lock()
+	defer c.orchestrationMutex.Unlock()
 	logger.Infof("start running mons")
 
 	if err := c.initClusterInfo(); err != nil {
@@ -156,7 +257,11 @@ func (c *Cluster) Start() error {
 	}
 
 	// create the mons for a new cluster or ensure mons are running in an existing cluster
-	return c.startMons()
+	if err := c.startMons(); err != nil {
+		return err
+	}
+
+	return c.saveCephVersion()
 }
 
 func (c *Cluster) startMons() error {
@@ -164,7 +269,7 @@ func (c *Cluster) startMons() error {
 	mons := c.initMonConfig(c.Count)
 
 	// Assign the pods to nodes
-	if err := c.assignMons(mons); err != nil {
+	if err := c.assignMons(mons, ""); err != nil {
 		return fmt.Errorf("failed to assign pods to mons. %+v", err)
 	}
 
@@ -212,6 +317,21 @@ func (c *Cluster) initClusterInfo() error {
 		return fmt.Errorf("failed to get cluster info. %+v", err)
 	}
 
+	// restore the out of quorum timeouts so an operator restart doesn't reset them
+	monTimeouts, err := loadMonTimeouts(c.context.Clientset, c.Namespace, c.clusterInfo.Monitors)
+	if err != nil {
+		return fmt.Errorf("failed to load mon timeouts. %+v", err)
+	}
+	c.monTimeoutMutex.Lock()
+	c.monTimeoutList = monTimeouts
+	c.monTimeoutMutex.Unlock()
+
+	// never start the mons on a lower ceph version than they already ran
+	c.lastVersion = loadCephVersion(c.context.Clientset, c.Namespace)
+	if err := c.checkDowngrade(); err != nil {
+		return err
+	}
+
 	// save cluster monitor config
 	if err = c.saveMonConfig(); err != nil {
 		return fmt.Errorf("failed to save mons. %+v", err)
@@ -220,6 +340,108 @@ func (c *Cluster) initClusterInfo() error {
 	return nil
 }
 
+// checkDowngrade refuses a ceph version lower than the last version the mons ran, since ceph does not
+// support downgrades. The downgrade is only allowed with the allow-downgrade annotation on the cluster CRD.
+// The DowngradeBlocked condition of the cluster CRD is set when the downgrade is refused.
+func (c *Cluster) checkDowngrade() error {
+	detected := c.CephVersion()
+	if !versionDetected(detected) {
+		c.log.Warningf("ceph version is not known, cannot check for a downgrade from %s", c.lastVersion.String())
+		return nil
+	}
+	if c.lastVersion.IsUnknown() || !detected.LessThan(c.lastVersion) {
+		c.setDowngradeBlocked(v1.ConditionFalse, "")
+		return nil
+	}
+
+	if c.downgradeAllowed() {
+		c.log.Warningf("downgrading ceph from %s to %s as allowed by the annotation %s", c.lastVersion.String(), detected.String(), AllowDowngradeAnnotation)
+		c.setDowngradeBlocked(v1.ConditionFalse, "")
+		return nil
+	}
+
+	message := fmt.Sprintf("ceph version %s is lower than version %s the mons already ran. ceph does not support downgrades, set the annotation %s=true on the cluster to downgrade anyway",
+		detected.String(), c.lastVersion.String(), AllowDowngradeAnnotation)
+	c.recordEvent(v1.EventTypeWarning, DowngradeBlockedReason, "%s", message)
+	c.setDowngradeBlocked(v1.ConditionTrue, message)
+	return fmt.Errorf("%s", message)
+}
+
+// saveCephVersion saves the version the mons run once they are started. The version is not saved before,
+// so a failed upgrade can still be rolled back to the version the mons ran without the downgrade annotation.
+func (c *Cluster) saveCephVersion() error {
+	detected := c.CephVersion()
+	if !versionDetected(detected) || detected.Equals(c.lastVersion) {
+		return nil
+	}
+
+	previous := c.lastVersion
+	c.lastVersion = detected
+	if err := c.saveMonConfig(); err != nil {
+		c.lastVersion = previous
+		return fmt.Errorf("failed to save ceph version %s of the mons. %+v", detected.String(), err)
+	}
+	return nil
+}
+
+// versionDetected checks that the version was detected on the ceph image, which is not the case when the
+// version is unknown or was never set
+func versionDetected(v cephver.CephVersion) bool {
+	return !v.IsUnknown() && !v.Equals(cephver.CephVersion{})
+}
+
+// downgradeAllowed checks if the cluster CRD has the annotation that allows a downgrade
+func (c *Cluster) downgradeAllowed() bool {
+	if c.context.RookClientset == nil {
+		return false
+	}
+	cluster, err := c.context.RookClientset.CephV1().CephClusters(c.Namespace).Get(c.ownerRef.Name, metav1.GetOptions{})
+	if err != nil {
+		c.log.Warningf("failed to get cluster %s to check if a downgrade is allowed. %+v", c.ownerRef.Name, err)
+		return false
+	}
+	return cluster.Annotations[AllowDowngradeAnnotation] == "true"
+}
+
+// setDowngradeBlocked sets the DowngradeBlocked condition of the cluster CRD. A condition that is not
+// blocked is only set to replace a blocked condition.
+func (c *Cluster) setDowngradeBlocked(status v1.ConditionStatus, message string) {
+	if c.context.RookClientset == nil {
+		return
+	}
+	cluster, err := c.context.RookClientset.CephV1().CephClusters(c.Namespace).Get(c.ownerRef.Name, metav1.GetOptions{})
+	if err != nil {
+		c.log.Warningf("failed to get cluster %s to set the %s condition. %+v", c.ownerRef.Name, cephv1.ClusterConditionDowngradeBlocked, err)
+		return
+	}
+	if status != v1.ConditionTrue && !hasClusterCondition(cluster.Status.Conditions, cephv1.ClusterConditionDowngradeBlocked) {
+		return
+	}
+
+	condition := cephv1.ClusterCondition{Type: cephv1.ClusterConditionDowngradeBlocked, Status: status, Message: message}
+	if status == v1.ConditionTrue {
+		condition.Reason = DowngradeBlockedReason
+	}
+	conditions, changed := setClusterCondition(cluster.Status.Conditions, condition, c.clock.Now())
+	if !changed {
+		return
+	}
+	cluster.Status.Conditions = conditions
+	if _, err := c.context.RookClientset.CephV1().CephClusters(c.Namespace).Update(cluster); err != nil {
+		c.log.Warningf("failed to set the %s condition on cluster %s. %+v", cephv1.ClusterConditionDowngradeBlocked, c.ownerRef.Name, err)
+	}
+}
+
+// hasClusterCondition checks if a condition of the given type is in the list of conditions
+func hasClusterCondition(conditions []cephv1.ClusterCondition, conditionType cephv1.ClusterConditionType) bool {
+	for _, condition := range conditions {
+		if condition.Type == conditionType {
+			return true
+		}
+	}
+	return false
+}
+
 func (c *Cluster) initMonConfig(size int) []*monConfig {
 	mons := []*monConfig{}
 
@@ -228,17 +450,67 @@ func (c *Cluster) initMonConfig(size int) []*monConfig {
 		mons = append(mons, &monConfig{ResourceName: resourceName(monitor.Name), DaemonName: monitor.Name, Port: getPortFromEndpoint(monitor.Endpoint)})
 	}
 
+	// reuse the mons whose deployment was created by an earlier start that did not finish, rather than
+	// creating more mons next to them
+	for _, name := range c.startedMonsNotInConfig() {
+		if len(mons) >= size {
+			break
+		}
+		c.log.Infof("mon %s was already started, not creating another mon in its place", name)
+		mons = append(mons, &monConfig{ResourceName: resourceName(name), DaemonName: name, Port: int32(mondaemon.DefaultPort)})
+	}
+
 	// initialize mon info if we don't have enough mons (at first startup)
-	for i := len(c.clusterInfo.Monitors); i < size; i++ {
-		c.maxMonID++
-		mons = append(mons, newMonConfig(c.maxMonID))
+	for i := len(mons); i < size; i++ {
+		mons = append(mons, newMonConfig(c.nextMonID()))
 	}
 
 	return mons
 }
 
+// startedMonsNotInConfig returns the names of the mons that have a deployment, but are missing from the
+// cluster info. The max mon id is raised to the ids of these mons so their ids are not handed out again.
+func (c *Cluster) startedMonsNotInConfig() []string {
+	selector := fmt.Sprintf("%s=%s,%s=%s", k8sutil.AppAttr, appName, k8sutil.ClusterAttr, c.Namespace)
+	deployments, err := c.context.Clientset.Extensions().Deployments(c.Namespace).List(metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		c.log.Warningf("failed to list the mon deployments. %+v", err)
+		return nil
+	}
+
+	names := []string{}
+	for _, d := range deployments.Items {
+		name, ok := d.Labels["mon"]
+		if !ok || d.DeletionTimestamp != nil {
+			continue
+		}
+		if _, ok := c.clusterInfo.Monitors[name]; ok {
+			continue
+		}
+		names = append(names, name)
+
+		if id, err := k8sutil.NameToIndex(name); err == nil {
+			c.monIDMutex.Lock()
+			if c.maxMonID < id {
+				c.maxMonID = id
+			}
+			c.monIDMutex.Unlock()
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// nextMonID increments the max mon id and returns it as the id of a new mon
+func (c *Cluster) nextMonID() int {
+	c.monIDMutex.Lock()
+	defer c.monIDMutex.Unlock()
+	c.maxMonID++
+	return c.maxMonID
+}
+
 func newMonConfig(monID int) *monConfig {
-	daemonName := k8sutil.IndexToName(monID)
+	daemonName := monNameForID(monID)
 	return &monConfig{ResourceName: resourceName(daemonName), DaemonName: daemonName, Port: int32(mondaemon.DefaultPort)}
 }
 
@@ -316,13 +588,21 @@ func (c *Cluster) createService(mon *monConfig) (string, error) {
 	return s.Spec.ClusterIP, nil
 }
 
-func (c *Cluster) assignMons(mons []*monConfig) error {
+// assignMons assigns the mons to nodes. The failure domain of the mon being replaced, if any, is
+// not considered in use so its replacement may be placed there.
+func (c *Cluster) assignMons(mons []*monConfig, replacing string) error {
 	// schedule the mons on different nodes if we have enough nodes to be unique
 	availableNodes, err := c.getMonNodes()
 	if err != nil {
 		return fmt.Errorf("failed to get available nodes for mons. %+v", err)
 	}
 
+	// spread the mons across failure domains if we have enough failure domains to be unique
+	domainsInUse, err := c.getFailureDomainsWithMons(replacing)
+	if err != nil {
+		return fmt.Errorf("failed to get failure domains with mons. %+v", err)
+	}
+
 	nodeIndex := 0
 	for _, m := range mons {
 		if _, ok := c.mapping.Node[m.DaemonName]; ok {
@@ -336,7 +616,10 @@ func (c *Cluster) assignMons(mons []*monConfig) error {
 		}
 
 		// pick one of the available nodes where the mon will be assigned
-		node := availableNodes[nodeIndex%len(availableNodes)]
+		node := c.pickMonNode(availableNodes, nodeIndex, domainsInUse)
+		if domain := node.Labels[c.topologyKey]; domain != "" {
+			domainsInUse[domain] = true
+		}
 		logger.Debugf("mon %s assigned to node %s", m.DaemonName, node.Name)
 		nodeInfo, err := getNodeInfoFromNode(node)
 		if err != nil {
@@ -430,10 +713,23 @@ func (c *Cluster) saveMonConfig() error {
 		return fmt.Errorf("failed to marshal mon mapping. %+v", err)
 	}
 
+	monTimeouts, err := json.Marshal(c.monTimeoutList)
+	if err != nil {
+		return fmt.Errorf("failed to marshal mon timeouts. %+v", err)
+	}
+
 	configMap.Data = map[string]string{
 		EndpointDataKey: mondaemon.FlattenMonEndpoints(c.clusterInfo.Monitors),
 		MaxMonIDKey:     strconv.Itoa(c.maxMonID),
 		MappingKey:      string(monMapping),
+		OutTimeoutsKey:  string(monTimeouts),
+	}
+	if !c.lastVersion.IsUnknown() {
+		version, err := json.Marshal(c.lastVersion)
+		if err != nil {
+			return fmt.Errorf("failed to marshal ceph version. %+v", err)
+		}
+		configMap.Data[CephVersionKey] = string(version)
 	}
 
 	if _, err := c.context.Clientset.CoreV1().ConfigMaps(c.Namespace).Create(configMap); err != nil {
@@ -457,6 +753,64 @@ func (c *Cluster) saveMonConfig() error {
 	return nil
 }
 
+// pickMonNode picks the node for a new mon from the available nodes, starting at the given index. A
+// node in a failure domain without a mon is preferred.
+func (c *Cluster) pickMonNode(nodes []v1.Node, index int, domainsInUse map[string]bool) v1.Node {
+	labeled := false
+	for i := 0; i < len(nodes); i++ {
+		node := nodes[(index+i)%len(nodes)]
+		domain := node.Labels[c.topologyKey]
+		if domain == "" {
+			continue
+		}
+		labeled = true
+		if !domainsInUse[domain] {
+			return node
+		}
+	}
+
+	// fall back to the next node when all failure domains already have a mon
+	node := nodes[index%len(nodes)]
+	if labeled {
+		c.log.Warningf("no node available in a failure domain (%s) without a mon, placing mon on node %s", c.topologyKey, node.Name)
+	}
+	return node
+}
+
+// getFailureDomainsWithMons returns the failure domains of the nodes the mons are assigned to,
+// except the node of the given mon
+func (c *Cluster) getFailureDomainsWithMons(excludeMon string) (map[string]bool, error) {
+	nodes, err := c.context.Clientset.CoreV1().Nodes().List(metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	nodeDomains := map[string]string{}
+	for _, node := range nodes.Items {
+		if domain := node.Labels[c.topologyKey]; domain != "" {
+			nodeDomains[node.Name] = domain
+		}
+	}
+
+	domains := map[string]bool{}
+	for name, nodeInfo := range c.mapping.Node {
+		if name == excludeMon {
+			continue
+		}
+		if domain, ok := nodeDomains[nodeInfo.Name]; ok {
+			domains[domain] = true
+		}
+	}
+	return domains, nil
+}
+
+// monTopologyKey returns the node label of the mon failure domains, defaulting to the zone
+func monTopologyKey(key string) string {
+	if key == "" {
+		return apis.LabelZoneFailureDomain
+	}
+	return key
+}
+
 // getMonNodes detects the nodes that are available for new mons to start.
 func (c *Cluster) getMonNodes() ([]v1.Node, error) {
 	availableNodes, nodes, err := c.getAvailableMonNodes()
@@ -478,7 +832,64 @@ func (c *Cluster) getMonNodes() ([]v1.Node, error) {
 		}
 	}
 
-	return availableNodes, nil
+	return c.preferredNodesFirst(c.withoutExcludedNodes(availableNodes)), nil
+}
+
+// preferredNodesFirst orders the nodes preferred by the node affinity of the mon placement before the
+// other nodes, so the new mons are placed on the preferred nodes when they are available
+func (c *Cluster) preferredNodesFirst(nodes []v1.Node) []v1.Node {
+	preferred := []v1.Node{}
+	others := []v1.Node{}
+	for _, node := range nodes {
+		if ok, err := k8sutil.PreferredNode(node, c.placement); err != nil {
+			c.log.Warningf("failed to check if node %s is preferred for mons. %+v", node.Name, err)
+			others = append(others, node)
+		} else if ok {
+			preferred = append(preferred, node)
+		} else {
+			others = append(others, node)
+		}
+	}
+	return append(preferred, others...)
+}
+
+// excludeNode keeps new mons off the node until the cooldown has passed
+func (c *Cluster) excludeNode(name string, cooldown time.Duration) {
+	if cooldown <= 0 {
+		return
+	}
+	until := c.clock.Now().Add(cooldown)
+	c.log.Infof("no new mons are placed on node %s until %s", name, until.Format(time.RFC3339))
+	c.excludedNodes[name] = until
+}
+
+// withoutExcludedNodes filters out the nodes that are still excluded from new mons. The exclusions that
+// expired are dropped. If all the nodes are excluded, they are all returned so a mon can still be placed.
+func (c *Cluster) withoutExcludedNodes(nodes []v1.Node) []v1.Node {
+	now := c.clock.Now()
+	for name, until := range c.excludedNodes {
+		if !now.Before(until) {
+			c.log.Infof("node %s is available for new mons again", name)
+			delete(c.excludedNodes, name)
+		}
+	}
+	if len(c.excludedNodes) == 0 {
+		return nodes
+	}
+
+	filtered := []v1.Node{}
+	for _, node := range nodes {
+		if until, ok := c.excludedNodes[node.Name]; ok {
+			c.log.Infof("skipping node %s for new mons until %s", node.Name, until.Format(time.RFC3339))
+			continue
+		}
+		filtered = append(filtered, node)
+	}
+	if len(filtered) == 0 {
+		c.log.Warningf("all %d nodes available for mons are excluded, placing the mons on them anyway", len(nodes))
+		return nodes
+	}
+	return filtered
 }
 
 func (c *Cluster) getAvailableMonNodes() ([]v1.Node, *v1.NodeList, error) {
@@ -28,16 +28,20 @@ import (
 
 	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
 	rookalpha "github.com/rook/rook/pkg/apis/rook.io/v1alpha2"
+	rookfake "github.com/rook/rook/pkg/client/clientset/versioned/fake"
 	"github.com/rook/rook/pkg/clusterd"
 	"github.com/rook/rook/pkg/daemon/ceph/client"
 	clienttest "github.com/rook/rook/pkg/daemon/ceph/client/test"
 	mondaemon "github.com/rook/rook/pkg/daemon/ceph/mon"
 	cephtest "github.com/rook/rook/pkg/daemon/ceph/test"
+	cephver "github.com/rook/rook/pkg/operator/ceph/version"
 	"github.com/rook/rook/pkg/operator/test"
 	exectest "github.com/rook/rook/pkg/util/exec/test"
 	"github.com/stretchr/testify/assert"
 	"k8s.io/api/core/v1"
+	extensions "k8s.io/api/extensions/v1beta1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/kubernetes/pkg/kubelet/apis"
 )
 
 func newTestStartCluster(namespace string) *clusterd.Context {
@@ -71,17 +75,33 @@ func newCluster(context *clusterd.Context, namespace string, hostNetwork bool, r
 		rookVersion:          "myversion",
 		Count:                3,
 		AllowMultiplePerNode: true,
+		lastVersion:          cephver.UnknownVersion(),
 		maxMonID:             -1,
 		waitForStart:         false,
 		monPodRetryInterval:  10 * time.Millisecond,
 		monPodTimeout:        1 * time.Second,
 		monTimeoutList:       map[string]time.Time{},
+		lastInQuorum:         map[string]time.Time{},
+		healthCheckInterval:  HealthCheckInterval,
+		monOutTimeout:        MonOutTimeout,
+		maxFailovers:         DefaultMaxConcurrentFailover,
+		maxRemovals:          DefaultMaxConcurrentRemoval,
+		maxFailoverAttempts:  DefaultMaxFailoverAttempts,
+		allowMonRemoval:      true,
+		failoverOrder:        FailoverOrderAddThenRemove,
+		failoverAttempts:     map[string]int{},
+		failoverBackoff:      map[string]*monFailoverBackoff{},
+		excludedNodes:        map[string]time.Time{},
+		topologyKey:          apis.LabelZoneFailureDomain,
 		mapping: &Mapping{
 			Node: map[string]*NodeInfo{},
 			Port: map[string]int32{},
 		},
 		resources: resources,
 		ownerRef:  metav1.OwnerReference{},
+		log:       newClusterLogger(namespace, ""),
+		clock:     realClock{},
+		monStatus: cephMonStatus{},
 	}
 }
 
@@ -152,6 +172,62 @@ func TestOperatorRestartHostNetwork(t *testing.T) {
 	validateStart(t, c)
 }
 
+func TestStartMonsTwice(t *testing.T) {
+	namespace := "ns"
+	context := newTestStartCluster(namespace)
+	c := newCluster(context, namespace, false, v1.ResourceRequirements{})
+	c.clusterInfo = test.CreateConfigDir(0)
+	monDeployments := func() []string {
+		deployments, err := context.Clientset.Extensions().Deployments(namespace).List(metav1.ListOptions{})
+		assert.Nil(t, err)
+		names := []string{}
+		for _, d := range deployments.Items {
+			names = append(names, d.Name)
+		}
+		return names
+	}
+
+	err := c.startMons()
+	assert.Nil(t, err)
+	assert.ElementsMatch(t, []string{"rook-ceph-mon-a", "rook-ceph-mon-b", "rook-ceph-mon-c"}, monDeployments())
+
+	// starting the mons again does not create more mons
+	err = c.startMons()
+	assert.Nil(t, err)
+	assert.ElementsMatch(t, []string{"rook-ceph-mon-a", "rook-ceph-mon-b", "rook-ceph-mon-c"}, monDeployments())
+
+	// the mons started before are reused when they are missing from the cluster info
+	c.clusterInfo = test.CreateConfigDir(0)
+	c.maxMonID = -1
+	err = c.startMons()
+	assert.Nil(t, err)
+	assert.ElementsMatch(t, []string{"rook-ceph-mon-a", "rook-ceph-mon-b", "rook-ceph-mon-c"}, monDeployments())
+	assert.Equal(t, 3, len(c.clusterInfo.Monitors))
+	assert.Equal(t, 2, c.maxMonID)
+}
+
+func TestUpdateKeepsHealthCheckState(t *testing.T) {
+	c := newCluster(&clusterd.Context{}, "ns", false, v1.ResourceRequirements{})
+	c.SetCephVersion(cephver.Luminous)
+	c.monTimeoutList["a"] = time.Now()
+	hc := NewHealthChecker(c)
+
+	// an update with a new ceph version reaches the mons of the running health checker
+	monSpec := cephv1.MonSpec{Count: 5, AllowEvenMonCount: true, HealthCheck: cephv1.MonHealthCheckSpec{Interval: "1m"}}
+	c.Update("rook/rook:v0.9.1", cephv1.CephVersionSpec{Image: "ceph/ceph:v13"}, monSpec, rookalpha.Placement{}, true, v1.ResourceRequirements{})
+	c.SetCephVersion(cephver.Mimic)
+	assert.Equal(t, cephver.Mimic, hc.monCluster.CephVersion())
+	assert.Equal(t, "rook/rook:v0.9.1", hc.monCluster.rookVersion)
+	assert.Equal(t, "ceph/ceph:v13", hc.monCluster.cephVersion.Image)
+	assert.Equal(t, 5, hc.monCluster.Count)
+	assert.True(t, hc.monCluster.AllowEvenMonCount)
+	assert.True(t, hc.monCluster.HostNetwork)
+	assert.Equal(t, time.Minute, hc.monCluster.getHealthCheckInterval())
+
+	// the state of the health check is kept
+	assert.Equal(t, 1, len(hc.monCluster.monTimeoutList))
+}
+
 func validateStart(t *testing.T, c *Cluster) {
 	s, err := c.context.Clientset.CoreV1().Secrets(c.Namespace).Get(appName, metav1.GetOptions{})
 	assert.Nil(t, err) // there shouldn't be an error due the secret existing
@@ -200,6 +276,120 @@ func TestSaveMonEndpoints(t *testing.T) {
 	assert.Equal(t, "2", cm.Data[MaxMonIDKey])
 }
 
+func TestCheckDowngrade(t *testing.T) {
+	configDir, _ := ioutil.TempDir("", "")
+	defer os.RemoveAll(configDir)
+	rookClientset := rookfake.NewSimpleClientset(&cephv1.CephCluster{ObjectMeta: metav1.ObjectMeta{Name: "ns", Namespace: "ns"}})
+	context := &clusterd.Context{Clientset: test.New(1), RookClientset: rookClientset, ConfigDir: configDir}
+	c := New(context, "ns", "", "myversion", cephv1.CephVersionSpec{},
+		cephv1.MonSpec{Count: 3, AllowMultiplePerNode: true}, rookalpha.Placement{}, false,
+		v1.ResourceRequirements{}, metav1.OwnerReference{Name: "ns"})
+	c.clusterInfo = test.CreateConfigDir(1)
+	downgradeBlocked := func() *cephv1.ClusterCondition {
+		cluster, err := rookClientset.CephV1().CephClusters("ns").Get("ns", metav1.GetOptions{})
+		assert.Nil(t, err)
+		for _, condition := range cluster.Status.Conditions {
+			if condition.Type == cephv1.ClusterConditionDowngradeBlocked {
+				return &condition
+			}
+		}
+		return nil
+	}
+
+	// the version is saved with the mons
+	assert.True(t, loadCephVersion(context.Clientset, "ns").IsUnknown())
+	c.lastVersion = cephver.CephVersion{Major: 14, Minor: 2, Patch: 5}
+	err := c.saveMonConfig()
+	assert.Nil(t, err)
+	assert.Equal(t, c.lastVersion, loadCephVersion(context.Clientset, "ns"))
+
+	// the same version
+	c.SetCephVersion(cephver.CephVersion{Major: 14, Minor: 2, Patch: 5})
+	err = c.checkDowngrade()
+	assert.Nil(t, err)
+	assert.Nil(t, downgradeBlocked())
+
+	// a higher version, which is only saved once the mons run it
+	c.SetCephVersion(cephver.CephVersion{Major: 14, Minor: 2, Patch: 6})
+	err = c.checkDowngrade()
+	assert.Nil(t, err)
+	assert.Equal(t, cephver.CephVersion{Major: 14, Minor: 2, Patch: 5}, c.lastVersion)
+	err = c.saveCephVersion()
+	assert.Nil(t, err)
+	assert.Equal(t, cephver.CephVersion{Major: 14, Minor: 2, Patch: 6}, c.lastVersion)
+	assert.Equal(t, c.lastVersion, loadCephVersion(context.Clientset, "ns"))
+
+	// a lower version, even by a patch
+	c.SetCephVersion(cephver.CephVersion{Major: 14, Minor: 2, Patch: 5})
+	err = c.checkDowngrade()
+	assert.NotNil(t, err)
+	assert.Equal(t, cephver.CephVersion{Major: 14, Minor: 2, Patch: 6}, c.lastVersion)
+	condition := downgradeBlocked()
+	if assert.NotNil(t, condition) {
+		assert.Equal(t, v1.ConditionTrue, condition.Status)
+		assert.Equal(t, DowngradeBlockedReason, condition.Reason)
+	}
+
+	// a version that was not detected is not blocked
+	c.SetCephVersion(cephver.UnknownVersion())
+	err = c.checkDowngrade()
+	assert.Nil(t, err)
+
+	// the annotation allows the downgrade
+	cluster, err := rookClientset.CephV1().CephClusters("ns").Get("ns", metav1.GetOptions{})
+	assert.Nil(t, err)
+	cluster.Annotations = map[string]string{AllowDowngradeAnnotation: "true"}
+	_, err = rookClientset.CephV1().CephClusters("ns").Update(cluster)
+	assert.Nil(t, err)
+	c.SetCephVersion(cephver.CephVersion{Major: 14, Minor: 2, Patch: 5})
+	err = c.checkDowngrade()
+	assert.Nil(t, err)
+	err = c.saveCephVersion()
+	assert.Nil(t, err)
+	assert.Equal(t, cephver.CephVersion{Major: 14, Minor: 2, Patch: 5}, c.lastVersion)
+	condition = downgradeBlocked()
+	if assert.NotNil(t, condition) {
+		assert.Equal(t, v1.ConditionFalse, condition.Status)
+	}
+}
+
+func TestRollbackFailedUpgrade(t *testing.T) {
+	updateDeployment := updateDeploymentAndWait
+	defer func() {
+		updateDeploymentAndWait = updateDeployment
+	}()
+	updateErr := fmt.Errorf("mock image pull failed")
+	updateDeploymentAndWait = func(context *clusterd.Context, deployment *extensions.Deployment, namespace string) error {
+		return updateErr
+	}
+	namespace := "ns"
+	context := newTestStartCluster(namespace)
+	c := newCluster(context, namespace, false, v1.ResourceRequirements{})
+	working := cephver.CephVersion{Major: 14, Minor: 2, Patch: 5}
+
+	// the mons run the working version
+	c.cephVersion.Image = "ceph/ceph:v14.2.5"
+	c.SetCephVersion(working)
+	err := c.Start()
+	assert.Nil(t, err)
+	assert.Equal(t, working, loadCephVersion(context.Clientset, namespace))
+
+	// the upgrade fails to start the mons, and the new version is not saved
+	c.cephVersion.Image = "ceph/ceph:v14.2.6"
+	c.SetCephVersion(cephver.CephVersion{Major: 14, Minor: 2, Patch: 6})
+	err = c.Start()
+	assert.NotNil(t, err)
+	assert.Equal(t, working, loadCephVersion(context.Clientset, namespace))
+
+	// rolling back to the working version is not a downgrade
+	updateErr = nil
+	c.cephVersion.Image = "ceph/ceph:v14.2.5"
+	c.SetCephVersion(working)
+	err = c.Start()
+	assert.Nil(t, err)
+	assert.Equal(t, working, loadCephVersion(context.Clientset, namespace))
+}
+
 func TestMonInQuorum(t *testing.T) {
 	entry := client.MonMapEntry{Name: "foo", Rank: 23}
 	quorum := []int{}
@@ -217,6 +407,32 @@ func TestMonInQuorum(t *testing.T) {
 	assert.False(t, monInQuorum(entry, quorum))
 }
 
+func TestAllMonsInQuorum(t *testing.T) {
+	status := client.MonStatusResponse{Quorum: []int{0, 1, 2}}
+	status.MonMap.Mons = []client.MonMapEntry{
+		{Name: "a", Rank: 0},
+		{Name: "b", Rank: 1},
+		{Name: "c", Rank: 2},
+	}
+
+	// all in quorum
+	assert.True(t, AllMonsInQuorum(status))
+	assert.Equal(t, []string{}, MonsOutOfQuorum(status))
+
+	// partial quorum
+	status.Quorum = []int{0, 2}
+	assert.False(t, AllMonsInQuorum(status))
+	assert.Equal(t, []string{"b"}, MonsOutOfQuorum(status))
+	status.Quorum = []int{}
+	assert.False(t, AllMonsInQuorum(status))
+	assert.Equal(t, []string{"a", "b", "c"}, MonsOutOfQuorum(status))
+
+	// an empty mon map
+	status.MonMap.Mons = []client.MonMapEntry{}
+	assert.False(t, AllMonsInQuorum(status))
+	assert.Equal(t, []string{}, MonsOutOfQuorum(status))
+}
+
 func TestNameToIndex(t *testing.T) {
 	// invalid
 	id, err := fullNameToIndex("m")
@@ -233,11 +449,69 @@ func TestNameToIndex(t *testing.T) {
 	id, err = fullNameToIndex("rook-ceph-mon-a")
 	assert.Nil(t, err)
 	assert.Equal(t, 0, id)
+	id, err = fullNameToIndex("rook-ceph-mon-b")
+	assert.Nil(t, err)
+	assert.Equal(t, 1, id)
+	id, err = fullNameToIndex("rook-ceph-mon-aa")
+	assert.Nil(t, err)
+	assert.Equal(t, 26, id)
 	id, err = fullNameToIndex("rook-ceph-mon123")
 	assert.Nil(t, err)
 	assert.Equal(t, 123, id)
 }
 
+func TestMonEndpointFromAddr(t *testing.T) {
+	endpoint, err := monEndpointFromAddr("10.0.0.1:6789/0")
+	assert.Nil(t, err)
+	assert.Equal(t, "10.0.0.1:6789", endpoint)
+	endpoint, err = monEndpointFromAddr("10.0.0.1:6790")
+	assert.Nil(t, err)
+	assert.Equal(t, "10.0.0.1:6790", endpoint)
+	endpoint, err = monEndpointFromAddr("10.0.0.1")
+	assert.Nil(t, err)
+	assert.Equal(t, "10.0.0.1:6790", endpoint)
+	endpoint, err = monEndpointFromAddr("[2001:db8::1]:6789/0")
+	assert.Nil(t, err)
+	assert.Equal(t, "[2001:db8::1]:6789", endpoint)
+
+	_, err = monEndpointFromAddr("")
+	assert.NotNil(t, err)
+	_, err = monEndpointFromAddr("/0")
+	assert.NotNil(t, err)
+	_, err = monEndpointFromAddr("not an address")
+	assert.NotNil(t, err)
+}
+
+func TestMonNameForID(t *testing.T) {
+	assert.Equal(t, "a", monNameForID(0))
+	assert.Equal(t, "z", monNameForID(25))
+	assert.Equal(t, "aa", monNameForID(26))
+	assert.Equal(t, "zz", monNameForID(701))
+	assert.Equal(t, "aaa", monNameForID(702))
+	assert.Equal(t, "rook-ceph-mon-d", resourceName(monNameForID(3)))
+
+	ids := []int{}
+	for id := 0; id < 1000; id++ {
+		ids = append(ids, id)
+	}
+	ids = append(ids, 17575, 17576, 456975, 1000000)
+	for _, id := range ids {
+		name := monNameForID(id)
+		index, err := monNameToIndex(name)
+		assert.Nil(t, err)
+		assert.Equal(t, id, index, name)
+
+		// the full name of the mon resources maps back to the same id
+		index, err = monNameToIndex(resourceName(name))
+		assert.Nil(t, err)
+		assert.Equal(t, id, index, resourceName(name))
+
+		config := newMonConfig(id)
+		assert.Equal(t, name, config.DaemonName)
+		assert.Equal(t, resourceName(name), config.ResourceName)
+	}
+}
+
 func TestAvailableMonNodes(t *testing.T) {
 	clientset := test.New(1)
 	c := New(&clusterd.Context{Clientset: clientset}, "ns", "", "myversion", cephv1.CephVersionSpec{},
@@ -464,7 +738,7 @@ func TestHostNetworkPortIncrease(t *testing.T) {
 	}
 	c.maxMonID = 1
 
-	err = c.assignMons(mons)
+	err = c.assignMons(mons, "")
 	assert.Nil(t, err)
 
 	err = c.initMonIPs(mons)
@@ -478,3 +752,118 @@ func TestHostNetworkPortIncrease(t *testing.T) {
 	sEndpoint = strings.Split(c.clusterInfo.Monitors["b"].Endpoint, ":")
 	assert.Equal(t, strconv.Itoa(mondaemon.DefaultPort+1), sEndpoint[1])
 }
+
+func TestRestoreMonTimeouts(t *testing.T) {
+	clientset := test.New(1)
+	configDir, _ := ioutil.TempDir("", "")
+	defer os.RemoveAll(configDir)
+	c := New(&clusterd.Context{Clientset: clientset, ConfigDir: configDir}, "ns", "", "myversion", cephv1.CephVersionSpec{},
+		cephv1.MonSpec{Count: 3, AllowMultiplePerNode: true}, rookalpha.Placement{}, false,
+		v1.ResourceRequirements{}, metav1.OwnerReference{})
+	c.clusterInfo = test.CreateConfigDir(2)
+
+	// mon z no longer exists and is pruned when loading
+	outSince := time.Now().Add(-2 * time.Minute)
+	c.monTimeoutList["a"] = outSince
+	c.monTimeoutList["z"] = outSince
+	err := c.saveMonConfig()
+	assert.Nil(t, err)
+
+	timeouts, err := loadMonTimeouts(clientset, c.Namespace, c.clusterInfo.Monitors)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(timeouts))
+	assert.True(t, outSince.Equal(timeouts["a"]))
+
+	// a timeout added by the health check is saved as well
+	c.monTimeoutList["b"] = outSince
+	c.saveMonTimeouts()
+	timeouts, err = loadMonTimeouts(clientset, c.Namespace, c.clusterInfo.Monitors)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(timeouts))
+	assert.True(t, outSince.Equal(timeouts["b"]))
+
+	// no config map means no timeouts
+	timeouts, err = loadMonTimeouts(clientset, "other-ns", c.clusterInfo.Monitors)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(timeouts))
+}
+
+func TestAssignMonsAcrossFailureDomains(t *testing.T) {
+	clientset := test.New(4)
+	zones := map[string]string{"node0": "zone-a", "node1": "zone-a", "node2": "zone-b", "node3": "zone-c"}
+	for name, zone := range zones {
+		node, err := clientset.CoreV1().Nodes().Get(name, metav1.GetOptions{})
+		assert.Nil(t, err)
+		node.Labels = map[string]string{apis.LabelZoneFailureDomain: zone}
+		clientset.CoreV1().Nodes().Update(node)
+	}
+	c := newCluster(&clusterd.Context{Clientset: clientset}, "ns", false, v1.ResourceRequirements{})
+	c.clusterInfo = test.CreateConfigDir(1)
+	c.mapping.Node["a"] = &NodeInfo{Name: "node0"}
+
+	// the new mons are placed in the zones without a mon
+	mons := []*monConfig{newMonConfig(1), newMonConfig(2)}
+	err := c.assignMons(mons, "")
+	assert.Nil(t, err)
+	assigned := []string{zones[c.mapping.Node["b"].Name], zones[c.mapping.Node["c"].Name]}
+	assert.ElementsMatch(t, []string{"zone-b", "zone-c"}, assigned)
+
+	// the zone of the mon being replaced is available for its replacement
+	delete(c.mapping.Node, "c")
+	domains, err := c.getFailureDomainsWithMons("a")
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]bool{"zone-b": true}, domains)
+
+	// all zones have a mon, so the mon is placed on any node
+	err = c.assignMons([]*monConfig{newMonConfig(3), newMonConfig(4)}, "")
+	assert.Nil(t, err)
+	assert.Equal(t, "zone-c", zones[c.mapping.Node["d"].Name])
+	assert.NotNil(t, c.mapping.Node["e"])
+
+	// a custom topology key
+	c.topologyKey = "rack"
+	nodes, _, err := c.getAvailableMonNodes()
+	assert.Nil(t, err)
+	nodes[1].Labels["rack"] = "rack1"
+	nodes[2].Labels["rack"] = "rack2"
+	node := c.pickMonNode(nodes, 0, map[string]bool{"rack1": true})
+	assert.Equal(t, nodes[2].Name, node.Name)
+
+	// no node is labeled with the topology key
+	c.topologyKey = "row"
+	node = c.pickMonNode(nodes, 1, map[string]bool{})
+	assert.Equal(t, nodes[1].Name, node.Name)
+}
+
+func TestAssignMonsExcludesFailedNode(t *testing.T) {
+	clientset := test.New(3)
+	c := newCluster(&clusterd.Context{Clientset: clientset}, "ns", false, v1.ResourceRequirements{})
+	c.clusterInfo = test.CreateConfigDir(0)
+	clock := &fakeClock{now: time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)}
+	c.clock = clock
+
+	// the node of a failed mon is not picked during the cooldown
+	c.excludeNode("node0", time.Minute)
+	err := c.assignMons([]*monConfig{newMonConfig(0), newMonConfig(1)}, "")
+	assert.Nil(t, err)
+	assert.NotEqual(t, "node0", c.mapping.Node["a"].Name)
+	assert.NotEqual(t, "node0", c.mapping.Node["b"].Name)
+
+	// the node is a candidate again after the cooldown
+	clock.now = clock.now.Add(2 * time.Minute)
+	nodes, err := c.getMonNodes()
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(nodes))
+	assert.Equal(t, 0, len(c.excludedNodes))
+
+	// all the nodes are returned when all are excluded
+	for _, node := range nodes {
+		c.excludeNode(node.Name, time.Minute)
+	}
+	assert.Equal(t, 3, len(c.withoutExcludedNodes(nodes)))
+
+	// no cooldown does not exclude the node
+	c.excludedNodes = map[string]time.Time{}
+	c.excludeNode("node0", 0)
+	assert.Equal(t, 0, len(c.excludedNodes))
+}
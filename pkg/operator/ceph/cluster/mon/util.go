@@ -25,12 +25,14 @@ import (
 	"path"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/rook/rook/pkg/clusterd"
 	"github.com/rook/rook/pkg/daemon/ceph/client"
 	cephconfig "github.com/rook/rook/pkg/daemon/ceph/config"
 	mondaemon "github.com/rook/rook/pkg/daemon/ceph/mon"
+	cephver "github.com/rook/rook/pkg/operator/ceph/version"
 	"github.com/rook/rook/pkg/operator/k8sutil"
 	"github.com/rook/rook/pkg/util/exec"
 	"github.com/rook/rook/pkg/util/sys"
@@ -138,7 +140,7 @@ func loadMonConfig(clientset kubernetes.Interface, namespace string) (map[string
 
 	// Make sure the max id is consistent with the current monitors
 	for _, m := range monEndpointMap {
-		id, _ := fullNameToIndex(m.Name)
+		id, _ := monNameToIndex(m.Name)
 		if maxMonID < id {
 			maxMonID = id
 		}
@@ -153,6 +155,62 @@ func loadMonConfig(clientset kubernetes.Interface, namespace string) (map[string
 	return monEndpointMap, maxMonID, monMapping, nil
 }
 
+// loadCephVersion loads the highest ceph version the mons ran from the mon config map. The version is unknown
+// if it was not saved yet.
+func loadCephVersion(clientset kubernetes.Interface, namespace string) cephver.CephVersion {
+	cm, err := clientset.CoreV1().ConfigMaps(namespace).Get(EndpointConfigMapName, metav1.GetOptions{})
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			logger.Warningf("failed to get the mon config map to load the ceph version. %+v", err)
+		}
+		return cephver.UnknownVersion()
+	}
+
+	data, ok := cm.Data[CephVersionKey]
+	if !ok || data == "" {
+		return cephver.UnknownVersion()
+	}
+	var version cephver.CephVersion
+	if err := json.Unmarshal([]byte(data), &version); err != nil {
+		logger.Errorf("invalid ceph version in the mon config map. %+v", err)
+		return cephver.UnknownVersion()
+	}
+	return version
+}
+
+// loadMonTimeouts returns the times the mons were first seen out of quorum. Mons that no longer
+// exist are pruned.
+func loadMonTimeouts(clientset kubernetes.Interface, namespace string, monitors map[string]*cephconfig.MonInfo) (map[string]time.Time, error) {
+	monTimeouts := map[string]time.Time{}
+
+	cm, err := clientset.CoreV1().ConfigMaps(namespace).Get(EndpointConfigMapName, metav1.GetOptions{})
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			return nil, err
+		}
+		return monTimeouts, nil
+	}
+
+	data, ok := cm.Data[OutTimeoutsKey]
+	if !ok || data == "" {
+		return monTimeouts, nil
+	}
+	if err := json.Unmarshal([]byte(data), &monTimeouts); err != nil {
+		logger.Errorf("invalid JSON in mon timeouts. %+v", err)
+		return map[string]time.Time{}, nil
+	}
+
+	for name := range monTimeouts {
+		if _, ok := monitors[name]; !ok {
+			logger.Infof("removing mon %s from the mon out timeout list since it no longer exists", name)
+			delete(monTimeouts, name)
+		}
+	}
+
+	logger.Infof("loaded mon timeouts: %+v", monTimeouts)
+	return monTimeouts, nil
+}
+
 func createClusterAccessSecret(clientset kubernetes.Interface, namespace string, clusterInfo *cephconfig.ClusterInfo, ownerRef *metav1.OwnerReference) error {
 	logger.Infof("creating mon secrets for a new cluster")
 	var err error
@@ -190,6 +248,22 @@ func monInQuorum(monitor client.MonMapEntry, quorum []int) bool {
 	return false
 }
 
+// AllMonsInQuorum checks if all the mons of the mon map are in quorum. An empty mon map has no quorum.
+func AllMonsInQuorum(status client.MonStatusResponse) bool {
+	return len(status.MonMap.Mons) > 0 && len(MonsOutOfQuorum(status)) == 0
+}
+
+// MonsOutOfQuorum returns the names of the mons of the mon map that are not in quorum
+func MonsOutOfQuorum(status client.MonStatusResponse) []string {
+	out := []string{}
+	for _, mon := range status.MonMap.Mons {
+		if !monInQuorum(mon, status.Quorum) {
+			out = append(out, mon.Name)
+		}
+	}
+	return out
+}
+
 // create new cluster info (FSID, shared keys)
 func createNamedClusterInfo(context *clusterd.Context, clusterName string) (*cephconfig.ClusterInfo, error) {
 	fsid, err := uuid.NewRandom()
@@ -261,7 +335,7 @@ func extractKey(contents string) (string, error) {
 func fullNameToIndex(name string) (int, error) {
 	prefix := appName + "-"
 	if strings.Index(name, prefix) != -1 && len(prefix) < len(name) {
-		return k8sutil.NameToIndex(name[len(prefix)+1:])
+		return k8sutil.NameToIndex(name[len(prefix):])
 	}
 
 	// attempt to parse the legacy mon name
@@ -276,6 +350,38 @@ func fullNameToIndex(name string) (int, error) {
 	return id, nil
 }
 
+// monNameForID returns the daemon name of the mon with the numeric mon ID, such as "d" for 3. The resources
+// of the mon are named resourceName(monNameForID(id)), and monNameToIndex converts both names back to the ID.
+func monNameForID(id int) string {
+	return k8sutil.IndexToName(id)
+}
+
+// monNameToIndex converts either the daemon name of a mon such as "d" or its full name to the numeric mon ID
+func monNameToIndex(name string) (int, error) {
+	if id, err := fullNameToIndex(name); err == nil {
+		return id, nil
+	}
+	return k8sutil.NameToIndex(name)
+}
+
+// monEndpointFromAddr converts the address of a mon in the mon map, such as "10.0.0.1:6789/0", to the
+// endpoint of the mon in the cluster info. The default port is assumed when the address has no port.
+func monEndpointFromAddr(addr string) (string, error) {
+	if i := strings.Index(addr, "/"); i != -1 {
+		addr = addr[:i]
+	}
+	if addr == "" {
+		return "", fmt.Errorf("empty mon address")
+	}
+	if _, _, err := net.SplitHostPort(addr); err == nil {
+		return addr, nil
+	}
+	if net.ParseIP(addr) == nil {
+		return "", fmt.Errorf("invalid mon address %s", addr)
+	}
+	return net.JoinHostPort(addr, strconv.Itoa(mondaemon.DefaultPort)), nil
+}
+
 // getPortFromEndpoint return the port from an endpoint string (my-host:6790)
 func getPortFromEndpoint(endpoint string) int32 {
 	port := mondaemon.DefaultPort
@@ -0,0 +1,498 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package version provides the numeric Ceph version and the helpers to compare and gate on it.
+package version
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/coreos/pkg/capnslog"
+)
+
+// CephVersion represents the Ceph version format
+type CephVersion struct {
+	Major int
+	Minor int
+	Patch int
+}
+
+// CephVersionDetails is the full version information printed by `ceph --version`
+type CephVersionDetails struct {
+	Version CephVersion
+	// Release is the name of the release printed by ceph, such as "nautilus"
+	Release string
+	// CommitHash is the git commit the build is from, empty if it is not printed
+	CommitHash string
+	// IsStable is true for a stable release and false for a release candidate or a development build
+	IsStable bool
+	// Inferred is true when the numeric version was not printed and was inferred from the release name
+	Inferred bool
+}
+
+const (
+	// FeatureNFS is the feature of exporting CephFS and RGW over NFS with NFS Ganesha
+	FeatureNFS = "nfs"
+	// FeatureMsgr2 is the feature of the v2 wire protocol of the mons
+	FeatureMsgr2 = "msgr2"
+	// FeatureDeviceClasses is the feature of the CRUSH device classes
+	FeatureDeviceClasses = "device-classes"
+
+	unknownVersionString = "<unknown version>"
+	unknownMajor         = -1
+	noVersionHash        = "no_version"
+	stableQualifier      = "stable"
+)
+
+var (
+	// Luminous Ceph version
+	Luminous = CephVersion{12, 0, 0}
+	// Mimic Ceph version
+	Mimic = CephVersion{13, 0, 0}
+	// Nautilus Ceph version
+	Nautilus = CephVersion{14, 0, 0}
+	// Octopus Ceph version
+	Octopus = CephVersion{15, 0, 0}
+	// Pacific Ceph version
+	Pacific = CephVersion{16, 0, 0}
+
+	// supportedVersions are production-ready versions that rook supports
+	supportedVersions = []CephVersion{Luminous, Mimic}
+	// unsupportedVersions are versions that rook can run with allowUnsupported, but are still being tested
+	unsupportedVersions = []CephVersion{Nautilus, Octopus, Pacific}
+	// allVersions includes all supportedVersions as well as unreleased versions that are being tested with rook
+	allVersions = append(supportedVersions, unsupportedVersions...)
+	// names of the releases older than luminous, which are end-of-life
+	endOfLifeReleases = map[int]string{10: "jewel", 11: "kraken"}
+	// protects supportedVersions from the registration of more versions
+	supportedLock sync.RWMutex
+
+	// featureMinVersions are the lowest versions the features are available in
+	featureMinVersions = map[string]CephVersion{
+		FeatureNFS:           Nautilus,
+		FeatureMsgr2:         Nautilus,
+		FeatureDeviceClasses: Luminous,
+	}
+
+	// for parsing the output of `ceph --version`
+	versionPattern = regexp.MustCompile(`ceph version (\d+)\.(\d+)\.(\d+)`)
+	// for parsing the commit hash, the release name and the qualifier of `ceph --version`, such as
+	// "(ae699615bac534ea496ee965ac6192cb7e0e07c0) luminous (stable)"
+	detailsPattern = regexp.MustCompile(`ceph version \S+ \((\w+)\) (\w+) \((\w+)\)`)
+	// for parsing the canonical form of the version, such as "14.2.5"
+	numericPattern = regexp.MustCompile(`^(\d+)\.(\d+)\.(\d+)$`)
+
+	logger = capnslog.NewPackageLogger("github.com/rook/rook", "cephver")
+)
+
+// NewCephVersion returns the version with the major, minor and patch numbers, which cannot be negative.
+// Use UnknownVersion for a version that is not known.
+func NewCephVersion(major, minor, patch int) (CephVersion, error) {
+	if major < 0 || minor < 0 || patch < 0 {
+		return CephVersion{}, fmt.Errorf("invalid ceph version %d.%d.%d, the numbers cannot be negative", major, minor, patch)
+	}
+	return CephVersion{major, minor, patch}, nil
+}
+
+// UnknownVersion returns the version standing in for a version of ceph that is not known
+func UnknownVersion() CephVersion {
+	return CephVersion{unknownMajor, 0, 0}
+}
+
+// IsUnknown checks if the version is the version returned by UnknownVersion, or any other version with a
+// negative major number
+func (v CephVersion) IsUnknown() bool {
+	return v.Major < 0
+}
+
+func (v CephVersion) String() string {
+	return fmt.Sprintf("%d.%d.%d %s", v.Major, v.Minor, v.Patch, v.ReleaseName())
+}
+
+// ReleaseName is the name of the major release of the version, such as "nautilus"
+func (v CephVersion) ReleaseName() string {
+	switch v.Major {
+	case Luminous.Major:
+		return "luminous"
+	case Mimic.Major:
+		return "mimic"
+	case Nautilus.Major:
+		return "nautilus"
+	case Octopus.Major:
+		return "octopus"
+	case Pacific.Major:
+		return "pacific"
+	}
+	return unknownVersionString
+}
+
+// SupportedVersions returns the versions of the releases that are production-ready in rook
+func SupportedVersions() []CephVersion {
+	supportedLock.RLock()
+	defer supportedLock.RUnlock()
+	versions := make([]CephVersion, len(supportedVersions))
+	copy(versions, supportedVersions)
+	return versions
+}
+
+// RegisterSupportedVersion marks the major release of the version as production-ready, such as in a
+// build that qualified a release rook does not support yet
+func RegisterSupportedVersion(v CephVersion) {
+	supportedLock.Lock()
+	defer supportedLock.Unlock()
+	for _, s := range supportedVersions {
+		if v.IsRelease(s) {
+			return
+		}
+	}
+	logger.Infof("registering ceph release %s as supported", v.ReleaseName())
+	supportedVersions = append(supportedVersions, v)
+}
+
+// Supported checks if the major release of the version is production-ready in rook
+func (v CephVersion) Supported() bool {
+	supportedLock.RLock()
+	defer supportedLock.RUnlock()
+	for _, s := range supportedVersions {
+		if v.IsRelease(s) {
+			return true
+		}
+	}
+	return false
+}
+
+// SupportedWithReason checks if the major release of the version is production-ready in rook like
+// Supported, along with a message explaining why, such as for the status of the cluster CRD
+func (v CephVersion) SupportedWithReason() (bool, string) {
+	if v.Supported() {
+		return true, fmt.Sprintf("%s is supported by this operator build", v.ReleaseName())
+	}
+	for _, u := range unsupportedVersions {
+		if v.IsRelease(u) {
+			return false, fmt.Sprintf("%s is not yet production-supported by this operator build", v.ReleaseName())
+		}
+	}
+	if v.LessThan(Luminous) {
+		if name, ok := endOfLifeReleases[v.Major]; ok {
+			return false, fmt.Sprintf("version %d.x (%s) is end-of-life", v.Major, name)
+		}
+		return false, fmt.Sprintf("version %d.x is end-of-life", v.Major)
+	}
+	return false, fmt.Sprintf("version %d.x is not known to this operator build", v.Major)
+}
+
+// IsRelease checks if the version is part of the major release of the given version
+func (v CephVersion) IsRelease(other CephVersion) bool {
+	return v.Major == other.Major
+}
+
+// compare returns -1, 0 or 1 if the version is lower than, equal to or greater than the given version,
+// comparing the major, then the minor, then the patch numbers
+func (v CephVersion) compare(other CephVersion) int {
+	switch {
+	case v.Major != other.Major:
+		return compareInts(v.Major, other.Major)
+	case v.Minor != other.Minor:
+		return compareInts(v.Minor, other.Minor)
+	default:
+		return compareInts(v.Patch, other.Patch)
+	}
+}
+
+func compareInts(a, b int) int {
+	if a < b {
+		return -1
+	}
+	if a > b {
+		return 1
+	}
+	return 0
+}
+
+// LessThan checks that the version is lower than the given version, comparing the major, then the
+// minor, then the patch numbers
+func (v CephVersion) LessThan(other CephVersion) bool {
+	return v.compare(other) < 0
+}
+
+// Equals checks that the major, minor and patch numbers of the versions are the same
+func (v CephVersion) Equals(other CephVersion) bool {
+	return v.Major == other.Major && v.Minor == other.Minor && v.Patch == other.Patch
+}
+
+// AtLeast checks that the version is greater than or equal to the given version. It is the same as IsAtLeast.
+func (v CephVersion) AtLeast(other CephVersion) bool {
+	return v.IsAtLeast(other)
+}
+
+// IsAtLeast checks that the version is greater than or equal to the given version, comparing the major,
+// then the minor, then the patch numbers. With a release constant, such as IsAtLeast(Nautilus), it is
+// true for any point release of that release or a newer release.
+func (v CephVersion) IsAtLeast(other CephVersion) bool {
+	return v.compare(other) >= 0
+}
+
+// IsAtLeastVersion checks that the version is the given point release or newer, such as
+// IsAtLeastVersion(14, 2, 2) for a feature added in 14.2.2
+func (v CephVersion) IsAtLeastVersion(major, minor, patch int) bool {
+	return v.IsAtLeast(CephVersion{major, minor, patch})
+}
+
+// Between checks that the version is within the given range. Both bounds are inclusive, so the
+// version is in the range when it is greater than or equal to min and lower than or equal to max.
+func (v CephVersion) Between(min, max CephVersion) bool {
+	return v.AtLeast(min) && !max.LessThan(v)
+}
+
+// Max returns the newer of the two versions, comparing the major, then the minor, then the patch numbers
+func Max(a, b CephVersion) CephVersion {
+	if a.LessThan(b) {
+		return b
+	}
+	return a
+}
+
+// Min returns the older of the two versions, such as to find the lowest version running across daemons
+func Min(a, b CephVersion) CephVersion {
+	if b.LessThan(a) {
+		return b
+	}
+	return a
+}
+
+// ValidUpgrade checks that ceph can be upgraded from one version to the other, such as before the image of
+// a cluster is changed. Ceph only supports upgrading one major release at a time, such as from luminous to
+// mimic but not from luminous to nautilus, and never downgrading. Staying on the same version is valid.
+func ValidUpgrade(from, to CephVersion) error {
+	if from.IsUnknown() || to.IsUnknown() {
+		return fmt.Errorf("cannot validate the upgrade from ceph version %s to %s, the version is not known", from.String(), to.String())
+	}
+	if to.LessThan(from) {
+		return fmt.Errorf("downgrading ceph from version %s to %s is not supported", from.String(), to.String())
+	}
+	if to.Major > from.Major+1 {
+		return fmt.Errorf("upgrading ceph from version %s to %s skips a major release, upgrade one major release at a time", from.String(), to.String())
+	}
+	return nil
+}
+
+// AtLeastMajor checks that the major release is greater than or equal to the major release of the
+// given version. The minor and patch numbers are ignored.
+//
+// Deprecated: use IsAtLeast with a release constant, such as IsAtLeast(Nautilus), or IsAtLeastVersion
+// to check a point release.
+func (v CephVersion) AtLeastMajor(other CephVersion) bool {
+	return v.Major >= other.Major
+}
+
+// IsAtLeastPointRelease checks that the version is the given point release or newer, comparing the
+// major, minor and patch numbers. It is meant for gating a feature that was added in a point release,
+// such as a feature only available from 14.2.0 on, where checking the major release is not enough.
+//
+// Deprecated: use IsAtLeastVersion.
+func (v CephVersion) IsAtLeastPointRelease(major, minor, patch int) bool {
+	return v.IsAtLeastVersion(major, minor, patch)
+}
+
+// AtLeastLuminous checks that the version is Luminous or newer
+func (v CephVersion) AtLeastLuminous() bool {
+	return v.AtLeast(Luminous)
+}
+
+// AtLeastMimic checks that the version is Mimic or newer
+func (v CephVersion) AtLeastMimic() bool {
+	return v.AtLeast(Mimic)
+}
+
+// AtLeastNautilus checks that the version is Nautilus or newer
+func (v CephVersion) AtLeastNautilus() bool {
+	return v.AtLeast(Nautilus)
+}
+
+// FeatureEnabled checks if the feature is available in the version. An unknown feature is never enabled.
+func FeatureEnabled(name string, v CephVersion) bool {
+	min, ok := featureMinVersions[name]
+	if !ok {
+		logger.Warningf("unknown ceph feature %q", name)
+		return false
+	}
+	return v.AtLeast(min)
+}
+
+// ExtractCephVersion extracts the major, minor and patch version from the output of `ceph --version`.
+// Development builds do not print the numeric version, in which case the major version is inferred
+// from the release name and the minor and patch numbers are 0.
+func ExtractCephVersion(src string) (*CephVersion, error) {
+	v, inferred, err := extractCephVersion(src)
+	if err != nil {
+		return nil, err
+	}
+	if inferred {
+		logger.Warningf("numeric version not found, inferred ceph version %d.%d.%d from release %s", v.Major, v.Minor, v.Patch, v.ReleaseName())
+	}
+	return v, nil
+}
+
+// ExtractAllCephVersions extracts every distinct version from output that can contain the `ceph --version`
+// output of several daemons, in the order they are first found. More than one version means that the
+// daemons run mixed versions, such as in the middle of an upgrade.
+func ExtractAllCephVersions(src string) ([]CephVersion, error) {
+	matches := versionPattern.FindAllStringSubmatch(src, -1)
+	if len(matches) == 0 {
+		// the version can still be inferred from the release name
+		v, err := ExtractCephVersion(src)
+		if err != nil {
+			return nil, err
+		}
+		return []CephVersion{*v}, nil
+	}
+
+	versions := []CephVersion{}
+	for _, m := range matches {
+		v, err := parseVersionParts(m[1], m[2], m[3])
+		if err != nil {
+			return nil, err
+		}
+		if !containsVersion(versions, *v) {
+			versions = append(versions, *v)
+		}
+	}
+	return versions, nil
+}
+
+// ParseCephVersionsJSON parses the output of `ceph versions`, which maps each daemon type such as "mon"
+// to the `ceph --version` strings of its daemons and their counts. Returns the distinct versions of each
+// daemon type from the lowest to the highest, so more than one version means the daemons of the type run
+// mixed versions. The "overall" entry is returned like the daemon types.
+func ParseCephVersionsJSON(data []byte) (map[string][]CephVersion, error) {
+	var daemons map[string]map[string]int
+	if err := json.Unmarshal(data, &daemons); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal ceph versions. %+v", err)
+	}
+
+	result := map[string][]CephVersion{}
+	for daemonType, counts := range daemons {
+		versions := []CephVersion{}
+		for versionString := range counts {
+			v, err := ExtractCephVersion(versionString)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse the version of the %s daemons. %+v", daemonType, err)
+			}
+			if !containsVersion(versions, *v) {
+				versions = append(versions, *v)
+			}
+		}
+		sort.Slice(versions, func(i, j int) bool { return versions[i].LessThan(versions[j]) })
+		result[daemonType] = versions
+	}
+	return result, nil
+}
+
+func containsVersion(versions []CephVersion, v CephVersion) bool {
+	for _, other := range versions {
+		if other.Equals(v) {
+			return true
+		}
+	}
+	return false
+}
+
+// ExtractCephVersionDetailed extracts the version from the output of `ceph --version` along with
+// the release name, the commit hash and whether the build is a stable release
+func ExtractCephVersionDetailed(src string) (*CephVersionDetails, error) {
+	v, inferred, err := extractCephVersion(src)
+	if err != nil {
+		return nil, err
+	}
+
+	details := &CephVersionDetails{Version: *v, Release: v.ReleaseName(), Inferred: inferred}
+	m := detailsPattern.FindStringSubmatch(src)
+	if m == nil {
+		logger.Warningf("failed to parse the commit hash and release qualifier from: %s", src)
+		return details, nil
+	}
+	if m[1] != noVersionHash {
+		details.CommitHash = m[1]
+	}
+	details.Release = m[2]
+	details.IsStable = m[3] == stableQualifier
+	return details, nil
+}
+
+// extractCephVersion returns the version and whether it was inferred from the release name
+func extractCephVersion(src string) (*CephVersion, bool, error) {
+	m := versionPattern.FindStringSubmatch(src)
+	if m != nil {
+		v, err := parseVersionParts(m[1], m[2], m[3])
+		if err != nil {
+			return nil, false, err
+		}
+		return v, false, nil
+	}
+
+	for _, v := range allVersions {
+		if strings.Contains(src, v.ReleaseName()) {
+			return &CephVersion{v.Major, 0, 0}, true, nil
+		}
+	}
+
+	return nil, false, fmt.Errorf("failed to parse version from: %s", src)
+}
+
+func parseVersionParts(majorPart, minorPart, patchPart string) (*CephVersion, error) {
+	major, err := strconv.Atoi(majorPart)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse version major part: %q", majorPart)
+	}
+	minor, err := strconv.Atoi(minorPart)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse version minor part: %q", minorPart)
+	}
+	patch, err := strconv.Atoi(patchPart)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse version patch part: %q", patchPart)
+	}
+	return &CephVersion{major, minor, patch}, nil
+}
+
+// MarshalJSON encodes the version as a string in the form "14.2.5"
+func (v CephVersion) MarshalJSON() ([]byte, error) {
+	return json.Marshal(fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch))
+}
+
+// UnmarshalJSON decodes a version string in the form "14.2.5"
+func (v *CephVersion) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("failed to unmarshal ceph version %s. %+v", string(data), err)
+	}
+	m := numericPattern.FindStringSubmatch(s)
+	if m == nil {
+		return fmt.Errorf("failed to parse ceph version from %q", s)
+	}
+	parsed, err := parseVersionParts(m[1], m[2], m[3])
+	if err != nil {
+		return fmt.Errorf("failed to parse ceph version from %q. %+v", s, err)
+	}
+	*v = *parsed
+	return nil
+}
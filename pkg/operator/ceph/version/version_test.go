@@ -0,0 +1,548 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package version
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestToString(t *testing.T) {
+	assert.Equal(t, "12.0.0 luminous", Luminous.String())
+	assert.Equal(t, "13.0.0 mimic", Mimic.String())
+	assert.Equal(t, "14.0.0 nautilus", Nautilus.String())
+	assert.Equal(t, "15.0.0 octopus", Octopus.String())
+	assert.Equal(t, "16.0.0 pacific", Pacific.String())
+
+	received := CephVersion{14, 2, 5}
+	assert.Equal(t, "14.2.5 nautilus", received.String())
+	received = UnknownVersion()
+	assert.Equal(t, "-1.0.0 "+unknownVersionString, received.String())
+}
+
+func TestNewCephVersion(t *testing.T) {
+	v, err := NewCephVersion(14, 2, 5)
+	assert.Nil(t, err)
+	assert.Equal(t, CephVersion{14, 2, 5}, v)
+	assert.False(t, v.IsUnknown())
+	v, err = NewCephVersion(0, 0, 0)
+	assert.Nil(t, err)
+	assert.False(t, v.IsUnknown())
+
+	// negative numbers are rejected
+	_, err = NewCephVersion(-1, 0, 0)
+	assert.NotNil(t, err)
+	_, err = NewCephVersion(14, -2, 5)
+	assert.NotNil(t, err)
+	_, err = NewCephVersion(14, 2, -5)
+	assert.NotNil(t, err)
+
+	// the unknown version is explicit
+	assert.True(t, UnknownVersion().IsUnknown())
+	assert.Equal(t, unknownVersionString, UnknownVersion().ReleaseName())
+	assert.False(t, Luminous.IsUnknown())
+}
+
+func TestReleaseName(t *testing.T) {
+	assert.Equal(t, "luminous", Luminous.ReleaseName())
+	assert.Equal(t, "mimic", Mimic.ReleaseName())
+	assert.Equal(t, "nautilus", Nautilus.ReleaseName())
+	assert.Equal(t, "octopus", Octopus.ReleaseName())
+	assert.Equal(t, "pacific", Pacific.ReleaseName())
+
+	ver := CephVersion{15, 2, 1}
+	assert.Equal(t, "octopus", ver.ReleaseName())
+	ver = UnknownVersion()
+	assert.Equal(t, unknownVersionString, ver.ReleaseName())
+
+	// majors older or newer than the releases rook knows about
+	ver = CephVersion{11, 2, 1}
+	assert.Equal(t, unknownVersionString, ver.ReleaseName())
+	assert.Equal(t, "11.2.1 "+unknownVersionString, ver.String())
+	ver = CephVersion{17, 0, 0}
+	assert.Equal(t, unknownVersionString, ver.ReleaseName())
+	assert.Equal(t, "17.0.0 "+unknownVersionString, ver.String())
+}
+
+func TestSupported(t *testing.T) {
+	for _, v := range supportedVersions {
+		assert.True(t, v.Supported())
+	}
+	for _, v := range unsupportedVersions {
+		assert.False(t, v.Supported())
+	}
+	assert.Len(t, allVersions, len(supportedVersions)+len(unsupportedVersions))
+
+	// point releases of a supported release are supported
+	ver := CephVersion{13, 2, 4}
+	assert.True(t, ver.Supported())
+	ver = CephVersion{11, 2, 1}
+	assert.False(t, ver.Supported())
+}
+
+func TestSupportedWithReason(t *testing.T) {
+	supported, reason := CephVersion{13, 2, 5}.SupportedWithReason()
+	assert.True(t, supported)
+	assert.Equal(t, "mimic is supported by this operator build", reason)
+
+	supported, reason = Nautilus.SupportedWithReason()
+	assert.False(t, supported)
+	assert.Equal(t, "nautilus is not yet production-supported by this operator build", reason)
+
+	supported, reason = CephVersion{11, 2, 1}.SupportedWithReason()
+	assert.False(t, supported)
+	assert.Equal(t, "version 11.x (kraken) is end-of-life", reason)
+
+	supported, reason = CephVersion{9, 2, 1}.SupportedWithReason()
+	assert.False(t, supported)
+	assert.Equal(t, "version 9.x is end-of-life", reason)
+
+	supported, reason = CephVersion{17, 0, 0}.SupportedWithReason()
+	assert.False(t, supported)
+	assert.Equal(t, "version 17.x is not known to this operator build", reason)
+}
+
+func TestRegisterSupportedVersion(t *testing.T) {
+	defer func(versions []CephVersion) { supportedVersions = versions }(supportedVersions)
+	assert.Equal(t, []CephVersion{Luminous, Mimic}, SupportedVersions())
+
+	// a registered release is supported
+	patched := CephVersion{14, 2, 5}
+	assert.False(t, patched.Supported())
+	RegisterSupportedVersion(patched)
+	assert.True(t, patched.Supported())
+	assert.True(t, Nautilus.Supported())
+	assert.False(t, Octopus.Supported())
+	assert.Equal(t, []CephVersion{Luminous, Mimic, patched}, SupportedVersions())
+
+	// registering a release again has no effect
+	RegisterSupportedVersion(Nautilus)
+	RegisterSupportedVersion(Mimic)
+	assert.Len(t, SupportedVersions(), 3)
+
+	// the returned versions are a copy
+	versions := SupportedVersions()
+	versions[0] = Octopus
+	assert.False(t, Octopus.Supported())
+}
+
+func TestIsRelease(t *testing.T) {
+	ver := CephVersion{15, 2, 1}
+	assert.True(t, ver.IsRelease(Octopus))
+	assert.False(t, ver.IsRelease(Nautilus))
+	assert.False(t, ver.IsRelease(Pacific))
+}
+
+func TestAtLeast(t *testing.T) {
+	tests := []struct {
+		version  CephVersion
+		other    CephVersion
+		expected bool
+	}{
+		// equal versions
+		{CephVersion{14, 2, 5}, CephVersion{14, 2, 5}, true},
+		{Nautilus, Nautilus, true},
+		// differing only in major
+		{Mimic, Nautilus, false},
+		{Nautilus, Mimic, true},
+		{CephVersion{13, 9, 9}, CephVersion{14, 0, 0}, false},
+		// differing only in minor
+		{CephVersion{14, 2, 5}, CephVersion{14, 10, 5}, false},
+		{CephVersion{14, 10, 5}, CephVersion{14, 2, 5}, true},
+		// differing only in patch
+		{CephVersion{14, 2, 1}, CephVersion{14, 2, 2}, false},
+		{CephVersion{14, 2, 2}, CephVersion{14, 2, 1}, true},
+		// a higher minor wins over a lower patch
+		{CephVersion{14, 3, 0}, CephVersion{14, 2, 9}, true},
+	}
+
+	for _, test := range tests {
+		msg := fmt.Sprintf("%v >= %v", test.version, test.other)
+		assert.Equal(t, test.expected, test.version.AtLeast(test.other), msg)
+	}
+}
+
+func TestAtLeastMajor(t *testing.T) {
+	v := CephVersion{14, 2, 5}
+	assert.True(t, v.AtLeastMajor(CephVersion{14, 10, 0}))
+	assert.True(t, v.AtLeastMajor(Mimic))
+	assert.False(t, v.AtLeastMajor(CephVersion{15, 0, 0}))
+	assert.False(t, v.AtLeast(CephVersion{14, 10, 0}))
+}
+
+func TestIsAtLeastPointRelease(t *testing.T) {
+	assert.False(t, CephVersion{14, 1, 999}.IsAtLeastPointRelease(14, 2, 0))
+	assert.True(t, CephVersion{14, 2, 0}.IsAtLeastPointRelease(14, 2, 0))
+	assert.True(t, CephVersion{14, 2, 1}.IsAtLeastPointRelease(14, 2, 0))
+	assert.False(t, CephVersion{14, 2, 0}.IsAtLeastPointRelease(14, 2, 1))
+
+	// any release after the point release
+	assert.True(t, Octopus.IsAtLeastPointRelease(14, 2, 0))
+	assert.False(t, CephVersion{13, 2, 9}.IsAtLeastPointRelease(14, 2, 0))
+	// unlike the major release check
+	assert.True(t, Nautilus.IsRelease(CephVersion{14, 2, 0}))
+	assert.False(t, Nautilus.IsAtLeastPointRelease(14, 2, 0))
+}
+
+func TestIsAtLeast(t *testing.T) {
+	for _, v := range []CephVersion{Mimic, {13, 2, 5}, {13, 99, 99}} {
+		assert.False(t, v.IsAtLeast(Nautilus), v.String())
+	}
+	for _, v := range []CephVersion{Nautilus, {14, 1, 0}, {14, 2, 0}, {14, 2, 22}, Octopus} {
+		assert.True(t, v.IsAtLeast(Nautilus), v.String())
+	}
+	assert.True(t, CephVersion{14, 2, 2}.IsAtLeast(CephVersion{14, 2, 2}))
+	assert.False(t, CephVersion{14, 2, 1}.IsAtLeast(CephVersion{14, 2, 2}))
+}
+
+func TestIsAtLeastVersion(t *testing.T) {
+	assert.False(t, CephVersion{14, 2, 1}.IsAtLeastVersion(14, 2, 2))
+	assert.True(t, CephVersion{14, 2, 2}.IsAtLeastVersion(14, 2, 2))
+	assert.True(t, CephVersion{14, 3, 0}.IsAtLeastVersion(14, 2, 2))
+	assert.True(t, Octopus.IsAtLeastVersion(14, 2, 2))
+	assert.False(t, Nautilus.IsAtLeastVersion(14, 2, 2))
+	assert.False(t, CephVersion{13, 2, 9}.IsAtLeastVersion(14, 2, 2))
+}
+
+func TestAtLeastRelease(t *testing.T) {
+	assert.True(t, Luminous.AtLeastLuminous())
+	assert.False(t, CephVersion{11, 2, 1}.AtLeastLuminous())
+	assert.True(t, Mimic.AtLeastLuminous())
+
+	assert.True(t, Mimic.AtLeastMimic())
+	assert.False(t, CephVersion{12, 2, 8}.AtLeastMimic())
+	assert.True(t, CephVersion{13, 2, 2}.AtLeastMimic())
+
+	assert.True(t, Nautilus.AtLeastNautilus())
+	assert.False(t, CephVersion{13, 99, 99}.AtLeastNautilus())
+	assert.True(t, Octopus.AtLeastNautilus())
+}
+
+func TestFeatureEnabled(t *testing.T) {
+	// a feature from nautilus
+	assert.False(t, FeatureEnabled(FeatureNFS, Luminous))
+	assert.False(t, FeatureEnabled(FeatureNFS, CephVersion{13, 2, 5}))
+	assert.True(t, FeatureEnabled(FeatureNFS, Nautilus))
+	assert.True(t, FeatureEnabled(FeatureNFS, CephVersion{14, 2, 5}))
+	assert.True(t, FeatureEnabled(FeatureNFS, Octopus))
+	assert.True(t, FeatureEnabled("nfs", Nautilus))
+
+	assert.True(t, FeatureEnabled(FeatureDeviceClasses, Luminous))
+	assert.False(t, FeatureEnabled(FeatureMsgr2, Mimic))
+
+	// unknown features
+	assert.False(t, FeatureEnabled("unknown", Pacific))
+	assert.False(t, FeatureEnabled("", Pacific))
+}
+
+func TestExtractVersion(t *testing.T) {
+	// release build
+	v0c := "ceph version 12.2.8 (ae699615bac534ea496ee965ac6192cb7e0e07c0) luminous (stable)"
+	v0d := `
+root@7a97f5a78bc6:/# ceph --version
+ceph version 12.2.8 (ae699615bac534ea496ee965ac6192cb7e0e07c0) luminous (stable)
+`
+	v, err := ExtractCephVersion(v0c)
+	assert.Nil(t, err)
+	assert.Equal(t, &CephVersion{12, 2, 8}, v)
+	v, err = ExtractCephVersion(v0d)
+	assert.Nil(t, err)
+	assert.Equal(t, &CephVersion{12, 2, 8}, v)
+
+	// development build
+	v1c := "ceph version 14.1.33-403-g7ba6bece41 (7ba6bece4187eda5d05a9b84211fe6ba8dd287bd) nautilus (rc)"
+	v1d := `
+bin/ceph --version
+*** DEVELOPER MODE: setting PATH, PYTHONPATH and LD_LIBRARY_PATH ***
+ceph version Development (no_version) nautilus (rc)
+`
+	v, err = ExtractCephVersion(v1c)
+	assert.Nil(t, err)
+	assert.Equal(t, &CephVersion{14, 1, 33}, v)
+
+	// the numeric version is inferred from the release name
+	v, inferred, err := extractCephVersion(v1d)
+	assert.Nil(t, err)
+	assert.True(t, inferred)
+	assert.Equal(t, &CephVersion{14, 0, 0}, v)
+	v, err = ExtractCephVersion(v1d)
+	assert.Nil(t, err)
+	assert.Equal(t, &CephVersion{14, 0, 0}, v)
+	_, inferred, err = extractCephVersion(v0c)
+	assert.Nil(t, err)
+	assert.False(t, inferred)
+
+	// neither a numeric version nor a known release
+	v2c := "ceph version Development (no_version) kraken (rc)"
+	v, err = ExtractCephVersion(v2c)
+	assert.NotNil(t, err)
+	assert.Nil(t, v)
+	v, err = ExtractCephVersion("")
+	assert.NotNil(t, err)
+	assert.Nil(t, v)
+}
+
+func TestExtractAllVersions(t *testing.T) {
+	// two daemons on differing versions
+	mixed := `
+ceph version 13.2.2 (02899bfda814146b021136e9d8e80eba494e1126) mimic (stable)
+ceph version 14.2.1 (d555a9489eb35f84f2e1ef49b77e19da9d113972) nautilus (stable)
+ceph version 13.2.2 (02899bfda814146b021136e9d8e80eba494e1126) mimic (stable)
+`
+	versions, err := ExtractAllCephVersions(mixed)
+	assert.Nil(t, err)
+	assert.Equal(t, []CephVersion{{13, 2, 2}, {14, 2, 1}}, versions)
+	// the single version is still the first one found
+	v, err := ExtractCephVersion(mixed)
+	assert.Nil(t, err)
+	assert.Equal(t, &CephVersion{13, 2, 2}, v)
+
+	// repeated identical versions
+	same := `
+ceph version 12.2.8 (ae699615bac534ea496ee965ac6192cb7e0e07c0) luminous (stable)
+ceph version 12.2.8 (ae699615bac534ea496ee965ac6192cb7e0e07c0) luminous (stable)
+`
+	versions, err = ExtractAllCephVersions(same)
+	assert.Nil(t, err)
+	assert.Equal(t, []CephVersion{{12, 2, 8}}, versions)
+
+	// the version is inferred for a development build
+	versions, err = ExtractAllCephVersions("ceph version Development (no_version) nautilus (rc)")
+	assert.Nil(t, err)
+	assert.Equal(t, []CephVersion{Nautilus}, versions)
+
+	_, err = ExtractAllCephVersions("not a version")
+	assert.NotNil(t, err)
+}
+
+func TestLessThan(t *testing.T) {
+	// major rollover
+	assert.True(t, CephVersion{13, 99, 99}.LessThan(Nautilus))
+	assert.False(t, Nautilus.LessThan(CephVersion{13, 99, 99}))
+	assert.True(t, CephVersion{14, 99, 99}.LessThan(Octopus))
+
+	// minor rollover
+	assert.True(t, CephVersion{14, 1, 99}.LessThan(CephVersion{14, 2, 0}))
+	assert.False(t, CephVersion{14, 2, 0}.LessThan(CephVersion{14, 1, 99}))
+
+	// patch
+	assert.True(t, CephVersion{14, 2, 0}.LessThan(CephVersion{14, 2, 1}))
+
+	// equal versions are not less than each other
+	assert.False(t, Nautilus.LessThan(Nautilus))
+	assert.False(t, CephVersion{14, 2, 5}.LessThan(CephVersion{14, 2, 5}))
+}
+
+func TestEquals(t *testing.T) {
+	assert.True(t, Nautilus.Equals(CephVersion{14, 0, 0}))
+	assert.True(t, CephVersion{14, 2, 5}.Equals(CephVersion{14, 2, 5}))
+	assert.False(t, CephVersion{14, 2, 5}.Equals(CephVersion{14, 2, 6}))
+	assert.False(t, CephVersion{14, 2, 5}.Equals(CephVersion{14, 3, 5}))
+	assert.False(t, CephVersion{13, 99, 99}.Equals(Nautilus))
+}
+
+func TestBetween(t *testing.T) {
+	min := CephVersion{14, 2, 1}
+	max := CephVersion{14, 2, 8}
+
+	// the bounds are inclusive
+	assert.True(t, min.Between(min, max))
+	assert.True(t, max.Between(min, max))
+	assert.True(t, CephVersion{14, 2, 5}.Between(min, max))
+
+	// just outside each bound
+	assert.False(t, CephVersion{14, 2, 0}.Between(min, max))
+	assert.False(t, CephVersion{14, 2, 9}.Between(min, max))
+	assert.False(t, CephVersion{14, 1, 99}.Between(min, max))
+	assert.False(t, CephVersion{14, 3, 0}.Between(min, max))
+
+	// the comparison is not major-only
+	assert.False(t, Nautilus.Between(min, max))
+	assert.False(t, Mimic.Between(min, max))
+
+	// an empty range
+	assert.False(t, CephVersion{14, 2, 5}.Between(max, min))
+}
+
+func TestMaxMin(t *testing.T) {
+	older := CephVersion{14, 2, 1}
+	newer := CephVersion{14, 2, 8}
+
+	assert.Equal(t, newer, Max(older, newer))
+	assert.Equal(t, newer, Max(newer, older))
+	assert.Equal(t, older, Min(older, newer))
+	assert.Equal(t, older, Min(newer, older))
+
+	// the minor and patch numbers are compared
+	assert.Equal(t, CephVersion{13, 2, 9}, Max(Mimic, CephVersion{13, 2, 9}))
+	assert.Equal(t, Nautilus, Min(Nautilus, CephVersion{14, 1, 0}))
+
+	// equal versions
+	assert.Equal(t, older, Max(older, CephVersion{14, 2, 1}))
+	assert.Equal(t, older, Min(older, CephVersion{14, 2, 1}))
+
+	// the lowest version across several daemons
+	lowest := Pacific
+	for _, v := range []CephVersion{{14, 2, 5}, {15, 2, 0}, {14, 2, 2}} {
+		lowest = Min(lowest, v)
+	}
+	assert.Equal(t, CephVersion{14, 2, 2}, lowest)
+}
+
+func TestValidUpgrade(t *testing.T) {
+	// a single major release
+	assert.Nil(t, ValidUpgrade(CephVersion{12, 2, 8}, CephVersion{13, 2, 2}))
+	assert.Nil(t, ValidUpgrade(Mimic, Nautilus))
+
+	// point releases of the same major release, and no change
+	assert.Nil(t, ValidUpgrade(CephVersion{14, 2, 1}, CephVersion{14, 2, 5}))
+	assert.Nil(t, ValidUpgrade(CephVersion{14, 2, 5}, CephVersion{14, 2, 5}))
+
+	// skipping a major release
+	err := ValidUpgrade(CephVersion{12, 2, 8}, CephVersion{14, 2, 5})
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "skips a major release")
+
+	// downgrades
+	err = ValidUpgrade(Nautilus, Mimic)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "downgrading")
+	assert.NotNil(t, ValidUpgrade(CephVersion{14, 2, 5}, CephVersion{14, 2, 1}))
+
+	// unknown versions
+	assert.NotNil(t, ValidUpgrade(UnknownVersion(), Nautilus))
+	assert.NotNil(t, ValidUpgrade(Nautilus, UnknownVersion()))
+}
+
+func TestVersionJSON(t *testing.T) {
+	v := CephVersion{14, 2, 5}
+	data, err := json.Marshal(v)
+	assert.Nil(t, err)
+	assert.Equal(t, `"14.2.5"`, string(data))
+
+	var decoded CephVersion
+	err = json.Unmarshal(data, &decoded)
+	assert.Nil(t, err)
+	assert.True(t, v.Equals(decoded))
+
+	// the version round-trips as a field of a struct
+	type status struct {
+		Version CephVersion `json:"version"`
+	}
+	data, err = json.Marshal(status{Version: Nautilus})
+	assert.Nil(t, err)
+	assert.Equal(t, `{"version":"14.0.0"}`, string(data))
+	var s status
+	err = json.Unmarshal(data, &s)
+	assert.Nil(t, err)
+	assert.Equal(t, Nautilus, s.Version)
+
+	// garbage is an error
+	for _, bad := range []string{`"nautilus"`, `"14.2"`, `"14.2.5 nautilus"`, `14`, `{}`} {
+		decoded = CephVersion{}
+		err = json.Unmarshal([]byte(bad), &decoded)
+		assert.NotNil(t, err, bad)
+	}
+}
+
+func TestExtractVersionDetailed(t *testing.T) {
+	// single-line release form
+	v0c := "ceph version 12.2.8 (ae699615bac534ea496ee965ac6192cb7e0e07c0) luminous (stable)"
+	d, err := ExtractCephVersionDetailed(v0c)
+	assert.Nil(t, err)
+	assert.Equal(t, CephVersion{12, 2, 8}, d.Version)
+	assert.Equal(t, "luminous", d.Release)
+	assert.Equal(t, "ae699615bac534ea496ee965ac6192cb7e0e07c0", d.CommitHash)
+	assert.True(t, d.IsStable)
+	assert.False(t, d.Inferred)
+
+	// release candidate
+	v1c := "ceph version 14.1.33-403-g7ba6bece41 (7ba6bece4187eda5d05a9b84211fe6ba8dd287bd) nautilus (rc)"
+	d, err = ExtractCephVersionDetailed(v1c)
+	assert.Nil(t, err)
+	assert.Equal(t, CephVersion{14, 1, 33}, d.Version)
+	assert.Equal(t, "nautilus", d.Release)
+	assert.Equal(t, "7ba6bece4187eda5d05a9b84211fe6ba8dd287bd", d.CommitHash)
+	assert.False(t, d.IsStable)
+	assert.False(t, d.Inferred)
+
+	// multi-line developer mode
+	v1d := `
+bin/ceph --version
+*** DEVELOPER MODE: setting PATH, PYTHONPATH and LD_LIBRARY_PATH ***
+ceph version Development (no_version) nautilus (rc)
+`
+	d, err = ExtractCephVersionDetailed(v1d)
+	assert.Nil(t, err)
+	assert.Equal(t, Nautilus, d.Version)
+	assert.Equal(t, "nautilus", d.Release)
+	assert.Equal(t, "", d.CommitHash)
+	assert.False(t, d.IsStable)
+	assert.True(t, d.Inferred)
+
+	// the numeric version alone is enough
+	d, err = ExtractCephVersionDetailed("ceph version 13.2.2")
+	assert.Nil(t, err)
+	assert.Equal(t, CephVersion{13, 2, 2}, d.Version)
+	assert.Equal(t, "mimic", d.Release)
+	assert.Equal(t, "", d.CommitHash)
+	assert.False(t, d.IsStable)
+
+	_, err = ExtractCephVersionDetailed("not a version")
+	assert.NotNil(t, err)
+}
+
+func TestParseCephVersionsJSON(t *testing.T) {
+	output := `{
+    "mon": {
+        "ceph version 14.2.4 (75f4de193b3ea58512f204623e6c5c9ae6d04c8d) nautilus (stable)": 1,
+        "ceph version 14.2.5 (ad5bd132e1492173c85fda2cc863152730b16a92) nautilus (stable)": 2
+    },
+    "mgr": {
+        "ceph version 14.2.5 (ad5bd132e1492173c85fda2cc863152730b16a92) nautilus (stable)": 1
+    },
+    "osd": {
+        "ceph version 14.2.5 (ad5bd132e1492173c85fda2cc863152730b16a92) nautilus (stable)": 6
+    },
+    "mds": {},
+    "overall": {
+        "ceph version 14.2.4 (75f4de193b3ea58512f204623e6c5c9ae6d04c8d) nautilus (stable)": 1,
+        "ceph version 14.2.5 (ad5bd132e1492173c85fda2cc863152730b16a92) nautilus (stable)": 9
+    }
+}`
+	versions, err := ParseCephVersionsJSON([]byte(output))
+	assert.Nil(t, err)
+	assert.Equal(t, 5, len(versions))
+
+	// the mons run mixed versions, from the lowest to the highest
+	assert.Equal(t, []CephVersion{{14, 2, 4}, {14, 2, 5}}, versions["mon"])
+	assert.Equal(t, []CephVersion{{14, 2, 5}}, versions["mgr"])
+	assert.Equal(t, []CephVersion{{14, 2, 5}}, versions["osd"])
+	assert.Equal(t, 0, len(versions["mds"]))
+	assert.Equal(t, []CephVersion{{14, 2, 4}, {14, 2, 5}}, versions["overall"])
+
+	// invalid json
+	_, err = ParseCephVersionsJSON([]byte("not json"))
+	assert.NotNil(t, err)
+
+	// a version that cannot be parsed
+	_, err = ParseCephVersionsJSON([]byte(`{"mon": {"ceph version unknown": 3}}`))
+	assert.NotNil(t, err)
+}
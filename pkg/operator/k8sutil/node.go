@@ -79,6 +79,24 @@ func ValidNode(node v1.Node, placement rookalpha.Placement) (bool, error) {
 	return false, nil
 }
 
+// PreferredNode checks if the node matches one of the `PreferredDuringSchedulingIgnoredDuringExecution`
+// node affinity terms of the placement. No node is preferred when the placement has no such terms.
+func PreferredNode(node v1.Node, placement rookalpha.Placement) (bool, error) {
+	if placement.NodeAffinity == nil {
+		return false, nil
+	}
+	for _, term := range placement.NodeAffinity.PreferredDuringSchedulingIgnoredDuringExecution {
+		nodeSelector, err := helper.NodeSelectorRequirementsAsSelector(term.Preference.MatchExpressions)
+		if err != nil {
+			return false, fmt.Errorf("failed to parse MatchExpressions: %+v. %+v", term.Preference.MatchExpressions, err)
+		}
+		if nodeSelector.Matches(labels.Set(node.Labels)) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
 func GetValidNodes(rookNodes []rookalpha.Node, clientset kubernetes.Interface, placement rookalpha.Placement) []rookalpha.Node {
 	validNodes := []rookalpha.Node{}
 
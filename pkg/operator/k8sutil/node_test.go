@@ -67,3 +67,28 @@ func TestValidNode(t *testing.T) {
 	validNodes := GetValidNodes(nodes, clientset, placement)
 	assert.Equal(t, len(validNodes), 1)
 }
+
+func TestPreferredNode(t *testing.T) {
+	node := v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node0", Labels: map[string]string{"mon": "preferred"}}}
+
+	// no preferred terms
+	preferred, err := PreferredNode(node, rookalpha.Placement{})
+	assert.Nil(t, err)
+	assert.False(t, preferred)
+
+	placement := rookalpha.Placement{NodeAffinity: &v1.NodeAffinity{
+		PreferredDuringSchedulingIgnoredDuringExecution: []v1.PreferredSchedulingTerm{
+			{Weight: 1, Preference: v1.NodeSelectorTerm{MatchExpressions: []v1.NodeSelectorRequirement{
+				{Key: "mon", Operator: v1.NodeSelectorOpIn, Values: []string{"preferred"}},
+			}}},
+		},
+	}}
+	preferred, err = PreferredNode(node, placement)
+	assert.Nil(t, err)
+	assert.True(t, preferred)
+
+	node.Labels["mon"] = "other"
+	preferred, err = PreferredNode(node, placement)
+	assert.Nil(t, err)
+	assert.False(t, preferred)
+}
@@ -0,0 +1,196 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package k8sutil
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/rook/rook/pkg/clusterd"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+)
+
+var (
+	// how often the status of a statefulset is checked while waiting for it
+	statefulSetPollInterval = 2 * time.Second
+)
+
+// ScaleStatefulSetAndWait scales the statefulset to the given number of replicas and waits until that many
+// pods are ready. When scaling down, it also waits for the pods of the removed replicas to terminate.
+func ScaleStatefulSetAndWait(context *clusterd.Context, name, namespace string, replicas int32, timeout time.Duration) error {
+	original, err := context.Clientset.AppsV1().StatefulSets(namespace).Get(name, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get statefulset %s. %+v", name, err)
+	}
+	// a statefulset without replicas set runs a single replica
+	previous := int32(1)
+	if original.Spec.Replicas != nil {
+		previous = *original.Spec.Replicas
+	}
+
+	logger.Infof("scaling statefulset %s from %d to %d replicas", name, previous, replicas)
+	patch := fmt.Sprintf(`{"spec":{"replicas":%d}}`, replicas)
+	if _, err := context.Clientset.AppsV1().StatefulSets(namespace).Patch(name, types.MergePatchType, []byte(patch)); err != nil {
+		return fmt.Errorf("failed to scale statefulset %s. %+v", name, err)
+	}
+
+	deadline := time.Now().Add(timeout)
+	for {
+		ss, err := context.Clientset.AppsV1().StatefulSets(namespace).Get(name, metav1.GetOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to get statefulset %s. %+v", name, err)
+		}
+		if ss.Status.ObservedGeneration >= ss.Generation && ss.Status.ReadyReplicas == replicas {
+			terminated, err := statefulSetPodsTerminated(context.Clientset, name, namespace, replicas, previous)
+			if err != nil {
+				return err
+			}
+			if terminated {
+				logger.Infof("finished scaling statefulset %s to %d replicas", name, replicas)
+				return nil
+			}
+		}
+		logger.Debugf("statefulset %s status=%v", name, ss.Status)
+
+		if time.Now().Add(statefulSetPollInterval).After(deadline) {
+			break
+		}
+		time.Sleep(statefulSetPollInterval)
+	}
+
+	return fmt.Errorf("gave up waiting for statefulset %s to scale to %d replicas", name, replicas)
+}
+
+// statefulSetPodsTerminated checks that the pods of the replicas removed from a statefulset are gone. The
+// pods of a statefulset are named after the statefulset and their ordinal.
+func statefulSetPodsTerminated(clientset kubernetes.Interface, name, namespace string, replicas, previous int32) (bool, error) {
+	for i := replicas; i < previous; i++ {
+		podName := fmt.Sprintf("%s-%d", name, i)
+		_, err := clientset.CoreV1().Pods(namespace).Get(podName, metav1.GetOptions{})
+		if err == nil {
+			logger.Debugf("waiting for pod %s of statefulset %s to terminate", podName, name)
+			return false, nil
+		}
+		if !errors.IsNotFound(err) {
+			return false, fmt.Errorf("failed to get pod %s. %+v", podName, err)
+		}
+	}
+	return true, nil
+}
+
+// DeleteStatefulSet deletes a statefulset and its headless service, which is named after the app. A statefulset
+// or service that doesn't exist is not an error. If wait is true, waits for the statefulset and its pods to be
+// deleted.
+func DeleteStatefulSet(clientset kubernetes.Interface, name, appName, namespace string, wait bool) error {
+	logger.Infof("removing statefulset %s if it exists", name)
+	deleteAction := func(options *metav1.DeleteOptions) error {
+		return clientset.AppsV1().StatefulSets(namespace).Delete(name, options)
+	}
+	if wait {
+		getAction := func() error {
+			_, err := clientset.AppsV1().StatefulSets(namespace).Get(name, metav1.GetOptions{})
+			return err
+		}
+		// the foreground propagation keeps the statefulset around until its pods are deleted
+		if err := deleteResourceAndWait(namespace, name, "statefulset", deleteAction, getAction); err != nil {
+			return err
+		}
+	} else {
+		propagation := metav1.DeletePropagationForeground
+		if err := deleteAction(&metav1.DeleteOptions{PropagationPolicy: &propagation}); err != nil && !errors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete statefulset %s. %+v", name, err)
+		}
+	}
+
+	if err := clientset.CoreV1().Services(namespace).Delete(appName, &metav1.DeleteOptions{}); err != nil {
+		if !errors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete service %s of statefulset %s. %+v", appName, name, err)
+		}
+		logger.Debugf("service %s of statefulset %s was already gone", appName, name)
+	}
+	return nil
+}
+
+// WaitForStatefulSetDeletion waits until the statefulset and its pods are gone, such as before the volumes
+// of the pods are reused. A statefulset that doesn't exist is not an error.
+func WaitForStatefulSetDeletion(context *clusterd.Context, name, namespace string, timeout time.Duration) error {
+	ss, err := context.Clientset.AppsV1().StatefulSets(namespace).Get(name, metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			logger.Debugf("statefulset %s is already deleted", name)
+			return nil
+		}
+		return fmt.Errorf("failed to get statefulset %s. %+v", name, err)
+	}
+	selector := ""
+	if ss.Spec.Selector != nil {
+		s, err := metav1.LabelSelectorAsSelector(ss.Spec.Selector)
+		if err != nil {
+			return fmt.Errorf("failed to parse the selector of statefulset %s. %+v", name, err)
+		}
+		selector = s.String()
+	}
+
+	logger.Infof("waiting for statefulset %s and its pods to be deleted", name)
+	deadline := time.Now().Add(timeout)
+	for {
+		deleted, err := statefulSetDeleted(context.Clientset, name, namespace, selector)
+		if err != nil {
+			return err
+		}
+		if deleted {
+			logger.Infof("statefulset %s and its pods are deleted", name)
+			return nil
+		}
+
+		if time.Now().Add(statefulSetPollInterval).After(deadline) {
+			break
+		}
+		time.Sleep(statefulSetPollInterval)
+	}
+
+	return fmt.Errorf("gave up waiting for statefulset %s and its pods to be deleted", name)
+}
+
+// statefulSetDeleted checks that the statefulset is gone and that none of the pods matching its selector
+// are left. The pods of a statefulset are named after the statefulset and their ordinal.
+func statefulSetDeleted(clientset kubernetes.Interface, name, namespace, selector string) (bool, error) {
+	_, err := clientset.AppsV1().StatefulSets(namespace).Get(name, metav1.GetOptions{})
+	if err == nil {
+		logger.Debugf("statefulset %s still exists", name)
+		return false, nil
+	}
+	if !errors.IsNotFound(err) {
+		return false, fmt.Errorf("failed to get statefulset %s. %+v", name, err)
+	}
+
+	pods, err := clientset.CoreV1().Pods(namespace).List(metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return false, fmt.Errorf("failed to list the pods of statefulset %s. %+v", name, err)
+	}
+	podName := regexp.MustCompile(fmt.Sprintf(`^%s-\d+$`, regexp.QuoteMeta(name)))
+	for _, pod := range pods.Items {
+		if podName.MatchString(pod.Name) {
+			logger.Debugf("waiting for pod %s of statefulset %s to be deleted", pod.Name, name)
+			return false, nil
+		}
+	}
+	return true, nil
+}
@@ -0,0 +1,181 @@
+/*
+Copyright 2018 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package k8sutil
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/stretchr/testify/assert"
+	apps "k8s.io/api/apps/v1"
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// runStatefulSetController mimics the statefulset controller until stopped. The ready replicas are updated
+// before the pods of removed replicas are deleted, like when the pods take a while to terminate.
+func runStatefulSetController(clientset kubernetes.Interface, name, namespace string, stopCh chan struct{}) {
+	for {
+		select {
+		case <-stopCh:
+			return
+		case <-time.After(time.Millisecond):
+		}
+
+		ss, err := clientset.AppsV1().StatefulSets(namespace).Get(name, metav1.GetOptions{})
+		if err != nil {
+			continue
+		}
+		replicas := *ss.Spec.Replicas
+		if ss.Status.ReadyReplicas != replicas {
+			for i := ss.Status.ReadyReplicas; i < replicas; i++ {
+				clientset.CoreV1().Pods(namespace).Create(&v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("%s-%d", name, i)}})
+			}
+			// patch the status only so a concurrent scale is not overwritten
+			patch := fmt.Sprintf(`{"status":{"readyReplicas":%d}}`, replicas)
+			clientset.AppsV1().StatefulSets(namespace).Patch(name, types.MergePatchType, []byte(patch))
+			continue
+		}
+		pods, _ := clientset.CoreV1().Pods(namespace).List(metav1.ListOptions{})
+		for i := replicas; i < int32(len(pods.Items)); i++ {
+			clientset.CoreV1().Pods(namespace).Delete(fmt.Sprintf("%s-%d", name, i), &metav1.DeleteOptions{})
+		}
+	}
+}
+
+func TestScaleStatefulSetAndWait(t *testing.T) {
+	statefulSetPollInterval = time.Millisecond
+	clientset := fake.NewSimpleClientset()
+	context := &clusterd.Context{Clientset: clientset}
+	replicas := int32(1)
+	for _, name := range []string{"myss", "stuck"} {
+		ss := &apps.StatefulSet{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "ns"},
+			Spec:       apps.StatefulSetSpec{Replicas: &replicas},
+			Status:     apps.StatefulSetStatus{ReadyReplicas: 1},
+		}
+		_, err := clientset.AppsV1().StatefulSets("ns").Create(ss)
+		assert.Nil(t, err)
+	}
+	clientset.CoreV1().Pods("ns").Create(&v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "myss-0"}})
+
+	// nothing scales the statefulset without a controller
+	err := ScaleStatefulSetAndWait(context, "stuck", "ns", 3, 10*time.Millisecond)
+	assert.NotNil(t, err)
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	go runStatefulSetController(clientset, "myss", "ns", stopCh)
+
+	// scale up
+	err = ScaleStatefulSetAndWait(context, "myss", "ns", 3, 5*time.Second)
+	assert.Nil(t, err)
+	ss, err := clientset.AppsV1().StatefulSets("ns").Get("myss", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, int32(3), *ss.Spec.Replicas)
+	assert.Equal(t, int32(3), ss.Status.ReadyReplicas)
+
+	// scale down waits for the extra pods to be gone
+	err = ScaleStatefulSetAndWait(context, "myss", "ns", 1, 5*time.Second)
+	assert.Nil(t, err)
+	pods, err := clientset.CoreV1().Pods("ns").List(metav1.ListOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(pods.Items))
+	assert.Equal(t, "myss-0", pods.Items[0].Name)
+
+	// a missing statefulset fails
+	err = ScaleStatefulSetAndWait(context, "other", "ns", 1, 5*time.Second)
+	assert.NotNil(t, err)
+}
+
+func TestDeleteStatefulSet(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	for _, name := range []string{"myss", "otherss"} {
+		_, err := clientset.AppsV1().StatefulSets("ns").Create(&apps.StatefulSet{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "ns"}})
+		assert.Nil(t, err)
+	}
+	_, err := clientset.CoreV1().Services("ns").Create(&v1.Service{ObjectMeta: metav1.ObjectMeta{Name: "myapp", Namespace: "ns"}})
+	assert.Nil(t, err)
+
+	// both the statefulset and the service are removed
+	err = DeleteStatefulSet(clientset, "myss", "myapp", "ns", true)
+	assert.Nil(t, err)
+	_, err = clientset.AppsV1().StatefulSets("ns").Get("myss", metav1.GetOptions{})
+	assert.True(t, errors.IsNotFound(err))
+	_, err = clientset.CoreV1().Services("ns").Get("myapp", metav1.GetOptions{})
+	assert.True(t, errors.IsNotFound(err))
+
+	// a missing service is not an error
+	err = DeleteStatefulSet(clientset, "otherss", "myapp", "ns", false)
+	assert.Nil(t, err)
+	_, err = clientset.AppsV1().StatefulSets("ns").Get("otherss", metav1.GetOptions{})
+	assert.True(t, errors.IsNotFound(err))
+
+	// nor is a missing statefulset
+	err = DeleteStatefulSet(clientset, "myss", "myapp", "ns", true)
+	assert.Nil(t, err)
+}
+
+func TestWaitForStatefulSetDeletion(t *testing.T) {
+	statefulSetPollInterval = time.Millisecond
+	clientset := fake.NewSimpleClientset()
+	context := &clusterd.Context{Clientset: clientset}
+
+	// a statefulset that is already gone
+	err := WaitForStatefulSetDeletion(context, "myss", "ns", 10*time.Millisecond)
+	assert.Nil(t, err)
+
+	labels := map[string]string{"app": "myapp"}
+	ss := &apps.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "myss", Namespace: "ns"},
+		Spec:       apps.StatefulSetSpec{Selector: &metav1.LabelSelector{MatchLabels: labels}},
+	}
+	_, err = clientset.AppsV1().StatefulSets("ns").Create(ss)
+	assert.Nil(t, err)
+	for _, name := range []string{"myss-0", "myss-1"} {
+		_, err = clientset.CoreV1().Pods("ns").Create(&v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels}})
+		assert.Nil(t, err)
+	}
+	// a pod with the same labels that doesn't belong to the statefulset
+	_, err = clientset.CoreV1().Pods("ns").Create(&v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "myapp-config", Labels: labels}})
+	assert.Nil(t, err)
+
+	// the statefulset is not deleted in time
+	err = WaitForStatefulSetDeletion(context, "myss", "ns", 10*time.Millisecond)
+	assert.NotNil(t, err)
+
+	// the statefulset is deleted before its pods
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		clientset.AppsV1().StatefulSets("ns").Delete("myss", &metav1.DeleteOptions{})
+		time.Sleep(10 * time.Millisecond)
+		clientset.CoreV1().Pods("ns").Delete("myss-1", &metav1.DeleteOptions{})
+		time.Sleep(10 * time.Millisecond)
+		clientset.CoreV1().Pods("ns").Delete("myss-0", &metav1.DeleteOptions{})
+	}()
+	err = WaitForStatefulSetDeletion(context, "myss", "ns", 5*time.Second)
+	assert.Nil(t, err)
+	pods, err := clientset.CoreV1().Pods("ns").List(metav1.ListOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(pods.Items))
+	assert.Equal(t, "myapp-config", pods.Items[0].Name)
+}